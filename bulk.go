@@ -0,0 +1,183 @@
+package qcloudsms
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// isDomestic 判断国家码是否为国内号码
+func isDomestic(nationcode string) bool {
+	return strings.TrimPrefix(nationcode, "+") == "86"
+}
+
+// splitBulk 将号码按国内和国际分组，每组再按 MULTISMSMAX 拆分为多个批次，返回每批号码在 tel 中的下标
+// 同一批次中不会同时出现国内和国际号码
+func splitBulk(tel []SMSTel) [][]int {
+	var domestic, international []int
+	for i, t := range tel {
+		if isDomestic(t.Nationcode) {
+			domestic = append(domestic, i)
+		} else {
+			international = append(international, i)
+		}
+	}
+
+	var batches [][]int
+	for _, group := range [][]int{domestic, international} {
+		for len(group) > 0 {
+			n := len(group)
+			if n > MULTISMSMAX {
+				n = MULTISMSMAX
+			}
+			batches = append(batches, group[:n])
+			group = group[n:]
+		}
+	}
+
+	return batches
+}
+
+// telKey 返回号码用于匹配发送结果的 key
+func telKey(nationcode, mobile string) string {
+	return strings.TrimPrefix(nationcode, "+") + "-" + mobile
+}
+
+// placeDetail 将一个批次的发送结果按号码放到 placed 中号码在 tel 中的位置
+// 接口没有返回结果的号码保持为 nil，返回无法对应到号码的结果
+func placeDetail(tel []SMSTel, batch []int, ds []SMSMultiDetail, placed []*SMSMultiDetail) []SMSMultiDetail {
+	index := make(map[string][]int, len(batch))
+	for _, i := range batch {
+		k := telKey(tel[i].Nationcode, tel[i].Mobile)
+		index[k] = append(index[k], i)
+	}
+
+	var extra []SMSMultiDetail
+	for j := range ds {
+		k := telKey(ds[j].Nationcode, ds[j].Mobile)
+		if is := index[k]; len(is) > 0 {
+			placed[is[0]] = &ds[j]
+			index[k] = is[1:]
+			continue
+		}
+		extra = append(extra, ds[j])
+	}
+
+	return extra
+}
+
+// failedResult 返回请求错误对应的错误码和错误信息
+// 没有接口错误码时（如网络错误）返回 REQUESTFAILED
+func failedResult(err error) (uint, string) {
+	var e *APIError
+	if errors.As(err, &e) && e.Result != SUCCESS {
+		return e.Result, e.Errmsg
+	}
+
+	return REQUESTFAILED, err.Error()
+}
+
+// batchFailed 批次请求失败时，为该批次每个号码生成失败结果
+func batchFailed(tel []SMSTel, batch []int, err error) []SMSMultiDetail {
+	result, errmsg := failedResult(err)
+
+	ds := make([]SMSMultiDetail, 0, len(batch))
+	for _, i := range batch {
+		ds = append(ds, SMSMultiDetail{
+			Result:     result,
+			Errmsg:     errmsg,
+			Mobile:     tel[i].Mobile,
+			Nationcode: tel[i].Nationcode,
+		})
+	}
+
+	return ds
+}
+
+// SendSMSBulk 批量群发短信，号码数量不受 MULTISMSMAX 的限制
+//
+// 号码按国内和国际分组后，每 MULTISMSMAX 个为一批，以 Options.BulkConcurrency 的并发数发送，
+// 返回合并后每个号码的发送结果，Detail 按传入号码的顺序排列。
+// 某个批次请求失败时，该批次的号码以失败结果记入 Detail，返回的 error 为第一个失败批次的错误
+func (c *QcloudSMS) SendSMSBulk(sms SMSMultiReq) (SMSMultiResult, error) {
+	return c.SendSMSBulkContext(context.Background(), sms)
+}
+
+// SendSMSBulkContext 与 SendSMSBulk 相同，请求的取消、超时等由 ctx 控制
+// ctx 取消后尚未发送的批次不再发送，其号码以 ctx.Err() 记为失败
+func (c *QcloudSMS) SendSMSBulkContext(ctx context.Context, sms SMSMultiReq) (SMSMultiResult, error) {
+	batches := splitBulk(sms.Tel)
+	results := make([][]SMSMultiDetail, len(batches))
+	errs := make([]error, len(batches))
+
+	concurrency := c.Options.BulkConcurrency
+	if concurrency <= 0 {
+		concurrency = BULKCONCURRENCY
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		// ctx 已经取消时 select 可能仍然选中 sem，因此先检查 ctx
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			results[i] = batchFailed(sms.Tel, batch, err)
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			results[i] = batchFailed(sms.Tel, batch, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, batch []int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			req := sms
+			req.Tel = make([]SMSTel, 0, len(batch))
+			for _, j := range batch {
+				req.Tel = append(req.Tel, sms.Tel[j])
+			}
+
+			res, err := c.SendSMSMultiContext(ctx, req)
+			if err != nil {
+				errs[i] = err
+				results[i] = batchFailed(sms.Tel, batch, err)
+				return
+			}
+			results[i] = res.Detail
+		}(i, batch)
+	}
+	wg.Wait()
+
+	placed := make([]*SMSMultiDetail, len(sms.Tel))
+	var extra []SMSMultiDetail
+	for i, batch := range batches {
+		extra = append(extra, placeDetail(sms.Tel, batch, results[i], placed)...)
+	}
+
+	var res SMSMultiResult
+	res.Ext = sms.Ext
+	for _, d := range placed {
+		if d != nil {
+			res.Detail = append(res.Detail, *d)
+		}
+	}
+	res.Detail = append(res.Detail, extra...)
+
+	for _, err := range errs {
+		if err != nil {
+			res.Result, res.Errmsg = failedResult(err)
+
+			return res, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,152 @@
+package qcloudsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bulkServer 模拟群发接口，记录每个批次的号码
+type bulkServer struct {
+	mu       sync.Mutex
+	batches  [][]SMSTel
+	inflight int
+	peak     int
+
+	// 为 true 的国家码返回错误码 1016
+	reject map[string]bool
+	delay  time.Duration
+}
+
+func (s *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req SMSMultiReq
+	json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	s.batches = append(s.batches, req.Tel)
+	s.inflight++
+	if s.inflight > s.peak {
+		s.peak = s.inflight
+	}
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	s.inflight--
+	s.mu.Unlock()
+
+	if len(req.Tel) > 0 && s.reject[req.Tel[0].Nationcode] {
+		w.Write([]byte(`{"result":1016,"errmsg":"手机号格式错误"}`))
+		return
+	}
+
+	// 倒序返回，结果应按号码对应到传入的顺序
+	res := SMSMultiResult{Errmsg: "OK"}
+	for i := len(req.Tel) - 1; i >= 0; i-- {
+		t := req.Tel[i]
+		res.Detail = append(res.Detail, SMSMultiDetail{Errmsg: "OK", Nationcode: t.Nationcode, Mobile: t.Mobile, Sid: t.Mobile})
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// bulkTel 返回 450 个号码，第一个为国际号码，之后国内和国际号码交替出现
+func bulkTel() []SMSTel {
+	tel := []SMSTel{{Nationcode: "1", Mobile: "2025550000"}}
+	for i := 1; i < 450; i++ {
+		if i%3 == 0 {
+			tel = append(tel, SMSTel{Nationcode: "1", Mobile: fmt.Sprintf("20255%05d", i)})
+		} else {
+			tel = append(tel, SMSTel{Nationcode: "86", Mobile: fmt.Sprintf("138%08d", i)})
+		}
+	}
+
+	return tel
+}
+
+func TestSendSMSBulk(t *testing.T) {
+	srv := &bulkServer{delay: 10 * time.Millisecond}
+	c := newTestClient(t, srv)
+	c.Options.BulkConcurrency = 2
+
+	tel := bulkTel()
+	res, err := c.SendSMSBulk(SMSMultiReq{Tel: tel, Msg: "test", Ext: "ext"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 国内 300 个，国际 150 个
+	if len(srv.batches) != 3 {
+		t.Errorf("sent %d batches, want 3", len(srv.batches))
+	}
+	for i, b := range srv.batches {
+		if len(b) > MULTISMSMAX {
+			t.Errorf("batch %d has %d numbers", i, len(b))
+		}
+		for _, n := range b {
+			if isDomestic(n.Nationcode) != isDomestic(b[0].Nationcode) {
+				t.Errorf("batch %d mixes domestic and international numbers", i)
+				break
+			}
+		}
+	}
+	if srv.peak > 2 {
+		t.Errorf("%d concurrent requests, want at most 2", srv.peak)
+	}
+
+	if res.Ext != "ext" || len(res.Detail) != len(tel) {
+		t.Fatalf("got %d details, ext %q", len(res.Detail), res.Ext)
+	}
+	for i, d := range res.Detail {
+		if d.Tel() != tel[i] || d.Sid != tel[i].Mobile {
+			t.Fatalf("Detail[%d] = %+v, want %+v", i, d, tel[i])
+		}
+	}
+}
+
+func TestSendSMSBulkBatchFailed(t *testing.T) {
+	srv := &bulkServer{reject: map[string]bool{"1": true}}
+	c := newTestClient(t, srv)
+
+	tel := bulkTel()
+	res, err := c.SendSMSBulk(SMSMultiReq{Tel: tel, Msg: "test"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Result != 1016 || res.Result != 1016 {
+		t.Fatalf("err = %v, result = %d, want 1016", err, res.Result)
+	}
+	if len(res.Detail) != len(tel) {
+		t.Fatalf("got %d details, want %d", len(res.Detail), len(tel))
+	}
+	for i, d := range res.Detail {
+		failed := d.Err() != nil
+		if d.Tel() != tel[i] || failed == isDomestic(tel[i].Nationcode) {
+			t.Fatalf("Detail[%d] = %+v", i, d)
+		}
+	}
+}
+
+func TestSendSMSBulkCanceled(t *testing.T) {
+	srv := &bulkServer{}
+	c := newTestClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tel := bulkTel()
+	res, err := c.SendSMSBulkContext(ctx, SMSMultiReq{Tel: tel, Msg: "test"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(srv.batches) != 0 {
+		t.Errorf("sent %d batches after cancel", len(srv.batches))
+	}
+	if len(res.Detail) != len(tel) || res.Detail[0].Result != REQUESTFAILED || res.Detail[0].Tel() != tel[0] {
+		t.Errorf("Detail = %+v", res.Detail[:1])
+	}
+}
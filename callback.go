@@ -0,0 +1,283 @@
+package qcloudsms
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// CallbackResult 回调推送的应答结构
+// 腾讯云收到 result 为 0 的应答后不再重复推送
+type CallbackResult struct {
+	Result uint   `json:"result"`
+	Errmsg string `json:"errmsg"`
+}
+
+// callbackMaxBody 回调推送请求体的最大长度
+const callbackMaxBody = 4 << 20
+
+// serveCallback 读取回调推送的请求体，交由 handle 处理，并返回应答
+// handle 返回错误时应答 result 为 1，以便平台重新推送
+func serveCallback(w http.ResponseWriter, r *http.Request, handle func(ctx context.Context, body []byte) error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, callbackMaxBody))
+	if err != nil {
+		writeCallbackResult(w, err)
+		return
+	}
+
+	writeCallbackResult(w, handle(r.Context(), body))
+}
+
+// writeCallbackResult 根据处理结果写入应答
+func writeCallbackResult(w http.ResponseWriter, err error) {
+	res := CallbackResult{Result: SUCCESS, Errmsg: "OK"}
+	if err != nil {
+		res = CallbackResult{Result: 1, Errmsg: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// StatusHandlerFunc 处理一条短信下发状态
+//
+// StatusHandlerFunc 实现了 http.Handler，可以直接作为短信下发状态通知的回调地址使用：
+// 平台每次推送一个状态数组，其中每条状态都会调用一次 f，任何一次返回错误时应答失败
+type StatusHandlerFunc func(ctx context.Context, s SMSStatusResult) error
+
+// ServeHTTP 处理短信下发状态推送
+func (f StatusHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, f.handle)
+}
+
+// handle 解析推送的状态数组并逐条处理
+func (f StatusHandlerFunc) handle(ctx context.Context, body []byte) error {
+	var ss []SMSStatusResult
+	if err := json.Unmarshal(body, &ss); err != nil {
+		return err
+	}
+
+	for _, s := range ss {
+		if err := f(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplyEvent 一条短信回复
+type ReplyEvent struct {
+	Nationcode string
+	Mobile     string
+	// 用户回复的内容
+	Text string
+	// 短信签名
+	Sign string
+	// 用户回复的时间
+	Time time.Time
+	// 通道扩展码
+	Extend string
+}
+
+// Event 将 SMSReplyResult 转换为 ReplyEvent
+func (r SMSReplyResult) Event() ReplyEvent {
+	return ReplyEvent{
+		Nationcode: r.Nationcode,
+		Mobile:     r.Mobile,
+		Text:       r.Text,
+		Sign:       r.Sign,
+		Time:       time.Unix(r.Time, 0),
+		Extend:     r.Extend,
+	}
+}
+
+// ReplyHandlerFunc 处理一条短信回复
+//
+// ReplyHandlerFunc 实现了 http.Handler，可以直接作为短信回复的回调地址使用：
+// 平台每次推送一条回复，f 返回错误时应答失败
+type ReplyHandlerFunc func(ctx context.Context, e ReplyEvent) error
+
+// ServeHTTP 处理短信回复推送
+func (f ReplyHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, f.handle)
+}
+
+// handle 解析推送的短信回复并处理
+func (f ReplyHandlerFunc) handle(ctx context.Context, body []byte) error {
+	var reply SMSReplyResult
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return err
+	}
+
+	return f(ctx, reply.Event())
+}
+
+// 语音推送内容外层的字段名
+const (
+	voiceCodeKey    = "voicecode_callback"
+	voicePromptKey  = "voiceprompt_callback"
+	voiceKeyKey     = "voicekey_callback"
+	voiceFailureKey = "voice_failure_callback"
+)
+
+// VoiceStatus 语音验证码、语音通知的状态通知结构
+type VoiceStatus struct {
+	// 0 表示用户正常接听，1 表示用户未接听，2 表示呼叫异常
+	Result string `json:"result"`
+	// 用户接听时间，Unix 时间戳
+	AcceptTime string `json:"accept_time"`
+	// 主叫号码
+	CallFrom string `json:"call_from"`
+	// 发送语音时返回的 callid
+	Callid string `json:"callid"`
+	// 挂机时间，Unix 时间戳
+	EndCalltime string `json:"end_calltime"`
+	// 计费条数
+	Fee        string `json:"fee"`
+	Mobile     string `json:"mobile"`
+	Nationcode string `json:"nationcode"`
+	// 呼叫开始时间，Unix 时间戳
+	StartCalltime string `json:"start_calltime"`
+}
+
+// Answered 返回用户是否正常接听
+func (v VoiceStatus) Answered() bool {
+	return v.Result == "0"
+}
+
+// VoiceKeypress 语音通知按键通知结构
+type VoiceKeypress struct {
+	// 主叫号码
+	CallFrom string `json:"call_from"`
+	// 发送语音时返回的 callid
+	Callid string `json:"callid"`
+	// 用户按键
+	Keypress   string `json:"keypress"`
+	Mobile     string `json:"mobile"`
+	Nationcode string `json:"nationcode"`
+}
+
+// VoiceFailure 语音送达失败原因推送结构
+type VoiceFailure struct {
+	// 主叫号码
+	CallFrom string `json:"call_from"`
+	// 发送语音时返回的 callid
+	Callid string `json:"callid"`
+	// 失败原因码
+	FailureCode int `json:"failure_code"`
+	// 失败原因描述，如 空号、关机 等
+	FailureReason string `json:"failure_reason"`
+	Mobile        string `json:"mobile"`
+	Nationcode    string `json:"nationcode"`
+}
+
+// errVoiceKey 语音推送内容中缺少对应的字段
+type errVoiceKey string
+
+func (e errVoiceKey) Error() string {
+	return "推送内容中缺少 " + string(e)
+}
+
+// decodeVoice 解析语音推送，推送内容的外层为 {key: v}
+func decodeVoice(body []byte, key string, v interface{}) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return err
+	}
+
+	raw, ok := m[key]
+	if !ok {
+		return errVoiceKey(key)
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// VoiceCodeHandlerFunc 处理语音验证码状态通知
+//
+// VoiceCodeHandlerFunc 实现了 http.Handler，可以直接作为语音验证码状态通知的回调地址使用：
+// 平台每次推送一条通知，f 返回错误时应答失败
+type VoiceCodeHandlerFunc func(ctx context.Context, v VoiceStatus) error
+
+// ServeHTTP 处理语音验证码状态通知
+func (f VoiceCodeHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, f.handle)
+}
+
+func (f VoiceCodeHandlerFunc) handle(ctx context.Context, body []byte) error {
+	var v VoiceStatus
+	if err := decodeVoice(body, voiceCodeKey, &v); err != nil {
+		return err
+	}
+
+	return f(ctx, v)
+}
+
+// VoicePromptHandlerFunc 处理语音通知状态通知
+//
+// VoicePromptHandlerFunc 实现了 http.Handler，可以直接作为语音通知状态通知的回调地址使用：
+// 平台每次推送一条通知，f 返回错误时应答失败
+type VoicePromptHandlerFunc func(ctx context.Context, v VoiceStatus) error
+
+// ServeHTTP 处理语音通知状态通知
+func (f VoicePromptHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, f.handle)
+}
+
+func (f VoicePromptHandlerFunc) handle(ctx context.Context, body []byte) error {
+	var v VoiceStatus
+	if err := decodeVoice(body, voicePromptKey, &v); err != nil {
+		return err
+	}
+
+	return f(ctx, v)
+}
+
+// VoiceKeyHandlerFunc 处理语音通知按键通知
+//
+// VoiceKeyHandlerFunc 实现了 http.Handler，可以直接作为语音通知按键通知的回调地址使用：
+// 平台每次推送一条通知，f 返回错误时应答失败
+type VoiceKeyHandlerFunc func(ctx context.Context, k VoiceKeypress) error
+
+// ServeHTTP 处理语音通知按键通知
+func (f VoiceKeyHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, f.handle)
+}
+
+func (f VoiceKeyHandlerFunc) handle(ctx context.Context, body []byte) error {
+	var k VoiceKeypress
+	if err := decodeVoice(body, voiceKeyKey, &k); err != nil {
+		return err
+	}
+
+	return f(ctx, k)
+}
+
+// VoiceFailureHandlerFunc 处理语音送达失败原因推送
+//
+// VoiceFailureHandlerFunc 实现了 http.Handler，可以直接作为语音送达失败原因推送的回调地址使用：
+// 平台每次推送一条通知，f 返回错误时应答失败
+type VoiceFailureHandlerFunc func(ctx context.Context, f VoiceFailure) error
+
+// ServeHTTP 处理语音送达失败原因推送
+func (f VoiceFailureHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, f.handle)
+}
+
+func (f VoiceFailureHandlerFunc) handle(ctx context.Context, body []byte) error {
+	var v VoiceFailure
+	if err := decodeVoice(body, voiceFailureKey, &v); err != nil {
+		return err
+	}
+
+	return f(ctx, v)
+}
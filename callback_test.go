@@ -0,0 +1,144 @@
+package qcloudsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var errHandler = errors.New("handler failed")
+
+// callbackTest 一次回调推送及期望的应答
+type callbackTest struct {
+	name    string
+	handler http.Handler
+	method  string
+	body    string
+	// 期望的 HTTP 状态码，为 0 时为 200
+	status int
+	// 期望的应答 result
+	result uint
+	// 期望调用处理函数得到的内容，为空时不检查
+	want string
+}
+
+// recorder 记录处理函数收到的内容
+type recorder struct {
+	got []string
+}
+
+// record 记录 v 的 JSON，fail 为 true 时返回 errHandler
+func (r *recorder) record(v interface{}, fail bool) error {
+	if fail {
+		return errHandler
+	}
+
+	b, _ := json.Marshal(v)
+	r.got = append(r.got, string(b))
+
+	return nil
+}
+
+func runCallbackTests(t *testing.T, rec *recorder, tests []callbackTest) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.got = nil
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(method, "/callback", strings.NewReader(tt.body)))
+
+			status := tt.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if w.Code != status {
+				t.Fatalf("status = %d, want %d", w.Code, status)
+			}
+			if status != http.StatusOK {
+				return
+			}
+
+			var res CallbackResult
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("response %q: %v", w.Body.String(), err)
+			}
+			if res.Result != tt.result {
+				t.Errorf("result = %d (%s), want %d", res.Result, res.Errmsg, tt.result)
+			}
+			if tt.want != "" && strings.Join(rec.got, "\n") != tt.want {
+				t.Errorf("handled %v, want %s", rec.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusHandlerFunc(t *testing.T) {
+	rec := &recorder{}
+	h := StatusHandlerFunc(func(ctx context.Context, s SMSStatusResult) error {
+		return rec.record(s.Sid, s.Errmsg == "fail")
+	})
+
+	runCallbackTests(t, rec, []callbackTest{
+		{name: "statuses", handler: h, body: `[{"sid":"a","report_status":"SUCCESS"},{"sid":"b","report_status":"FAIL"}]`, want: "\"a\"\n\"b\""},
+		{name: "handler error", handler: h, body: `[{"sid":"a"},{"sid":"b","errmsg":"fail"}]`, result: 1},
+		{name: "malformed", handler: h, body: `{"sid":"a"}`, result: 1},
+		{name: "GET", handler: h, method: http.MethodGet, status: http.StatusMethodNotAllowed},
+	})
+}
+
+func TestReplyHandlerFunc(t *testing.T) {
+	rec := &recorder{}
+	h := ReplyHandlerFunc(func(ctx context.Context, e ReplyEvent) error {
+		return rec.record(e.Mobile+" "+e.Text+" "+e.Extend+" "+e.Time.UTC().Format("15:04:05"), e.Text == "fail")
+	})
+
+	runCallbackTests(t, rec, []callbackTest{
+		{name: "reply", handler: h, body: `{"nationcode":"86","mobile":"13800000000","text":"TD","sign":"签名","time":1500000000,"extend":"12"}`, want: `"13800000000 TD 12 02:40:00"`},
+		{name: "handler error", handler: h, body: `{"mobile":"13800000000","text":"fail"}`, result: 1},
+		{name: "malformed", handler: h, body: `[]`, result: 1},
+		{name: "GET", handler: h, method: http.MethodGet, status: http.StatusMethodNotAllowed},
+	})
+}
+
+func TestVoiceHandlerFuncs(t *testing.T) {
+	rec := &recorder{}
+	code := VoiceCodeHandlerFunc(func(ctx context.Context, v VoiceStatus) error {
+		return rec.record(v.Callid+" "+v.Result, v.Result == "fail")
+	})
+	prompt := VoicePromptHandlerFunc(func(ctx context.Context, v VoiceStatus) error {
+		return rec.record(v.Callid+" "+v.Result, v.Result == "fail")
+	})
+	key := VoiceKeyHandlerFunc(func(ctx context.Context, k VoiceKeypress) error {
+		return rec.record(k.Callid+" "+k.Keypress, k.Keypress == "fail")
+	})
+	failure := VoiceFailureHandlerFunc(func(ctx context.Context, f VoiceFailure) error {
+		return rec.record(f.Callid+" "+f.FailureReason, f.FailureReason == "fail")
+	})
+
+	runCallbackTests(t, rec, []callbackTest{
+		{name: "voicecode", handler: code, body: `{"voicecode_callback":{"result":"0","callid":"c1","mobile":"13800000000"}}`, want: `"c1 0"`},
+		{name: "voiceprompt", handler: prompt, body: `{"voiceprompt_callback":{"result":"1","callid":"c2"}}`, want: `"c2 1"`},
+		{name: "voicekey", handler: key, body: `{"voicekey_callback":{"callid":"c3","keypress":"2"}}`, want: `"c3 2"`},
+		{name: "voice failure", handler: failure, body: `{"voice_failure_callback":{"callid":"c4","failure_code":8,"failure_reason":"空号"}}`, want: `"c4 空号"`},
+		{name: "missing key", handler: code, body: `{"voiceprompt_callback":{"callid":"c2"}}`, result: 1},
+		{name: "handler error", handler: key, body: `{"voicekey_callback":{"keypress":"fail"}}`, result: 1},
+		{name: "malformed", handler: failure, body: `not json`, result: 1},
+		{name: "GET", handler: prompt, method: http.MethodGet, status: http.StatusMethodNotAllowed},
+	})
+}
+
+func TestDecodeVoiceMissingKey(t *testing.T) {
+	var v VoiceStatus
+	err := decodeVoice([]byte(`{"voiceprompt_callback":{}}`), voiceCodeKey, &v)
+	if err != errVoiceKey(voiceCodeKey) {
+		t.Errorf("err = %v, want errVoiceKey(%s)", err, voiceCodeKey)
+	}
+}
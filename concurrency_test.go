@@ -0,0 +1,65 @@
+package qcloudsms_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+	"github.com/qichengzx/qcloudsms_go/qcloudsmstest"
+)
+
+// TestConcurrentSend 在多个 goroutine 中使用同一个 client 发送，需配合 go test -race 运行
+func TestConcurrentSend(t *testing.T) {
+	srv := qcloudsmstest.NewServer("1400", "key")
+	defer srv.Close()
+
+	client := srv.NewClient()
+
+	const workers, perWorker = 16, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				mobile := fmt.Sprintf("138%04d%04d", w, i)
+				var err error
+				if i%2 == 0 {
+					_, err = client.SendSMSSingle(qcloudsms.SMSSingleReq{
+						Tel: qcloudsms.SMSTel{Nationcode: "86", Mobile: mobile},
+						Msg: "test",
+					})
+				} else {
+					_, err = client.SendSMSMulti(qcloudsms.SMSMultiReq{
+						Tel: []qcloudsms.SMSTel{{Nationcode: "86", Mobile: mobile}, {Nationcode: "86", Mobile: mobile + "1"}},
+						Msg: "test",
+					})
+				}
+				if err != nil {
+					errs <- fmt.Errorf("%s: %v", mobile, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != workers*perWorker {
+		t.Fatalf("server got %d requests, want %d", len(reqs), workers*perWorker)
+	}
+
+	randoms := make(map[string]bool)
+	for _, r := range reqs {
+		if randoms[r.Random] {
+			t.Errorf("random %s reused", r.Random)
+		}
+		randoms[r.Random] = true
+	}
+}
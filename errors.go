@@ -0,0 +1,232 @@
+package qcloudsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError 接口返回的错误
+//
+// HTTP 状态码不为 200，或返回的 result 不为 0 时，接口方法返回 *APIError，
+// 可以使用 errors.As 取得错误详情，或使用 errors.Is 与下方的错误码变量比较
+type APIError struct {
+	// 请求的接口，如 sendsms
+	API string
+	// HTTP 状态码
+	StatusCode int
+	// 接口返回的错误码
+	Result uint
+	// 接口返回的错误信息
+	Errmsg string
+	// 请求时传入的 ext，原样返回
+	Ext string
+	// 原始响应内容
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	prefix := ErrRequest.Error()
+	if e.API != "" {
+		prefix = e.API + " " + prefix
+	}
+
+	if e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("%s: HTTP %d", prefix, e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: %d %s", prefix, e.Result, e.Errmsg)
+}
+
+// Is 判断错误是否与 target 相同
+//
+// HTTP 状态码不为 200 时与 ErrRequest 相同，否则与 result 相同的错误码变量相同
+func (e *APIError) Is(target error) bool {
+	if target == ErrRequest {
+		return e.StatusCode != http.StatusOK
+	}
+
+	t, ok := target.(*APIError)
+	if !ok || t.Result == SUCCESS {
+		return false
+	}
+
+	return e.StatusCode == http.StatusOK && e.Result == t.Result
+}
+
+// resultError 生成一个用于比较的错误码变量
+func resultError(result uint, errmsg string) *APIError {
+	return &APIError{StatusCode: http.StatusOK, Result: result, Errmsg: errmsg}
+}
+
+// 常见的接口错误码，可以使用 errors.Is 判断
+//
+// 完整的错误码说明见 https://cloud.tencent.com/document/product/382/3771
+var (
+	// ErrSigVerify sig 校验失败
+	ErrSigVerify = resultError(1001, "sig 校验失败")
+	// ErrSensitiveWord 短信或语音内容中含有敏感词
+	ErrSensitiveWord = resultError(1002, "内容中含有敏感词")
+	// ErrSigEmpty 请求包体没有 sig 字段或 sig 为空
+	ErrSigEmpty = resultError(1003, "sig 为空")
+	// ErrBadPackage 请求包解析失败
+	ErrBadPackage = resultError(1004, "请求包解析失败")
+	// ErrNoPermission 请求没有权限
+	ErrNoPermission = resultError(1006, "请求没有权限")
+	// ErrSendTimeout 请求下发短信超时
+	ErrSendTimeout = resultError(1008, "请求下发短信超时")
+	// ErrIPForbidden 请求 IP 不在白名单中
+	ErrIPForbidden = resultError(1009, "请求 IP 不在白名单中")
+	// ErrAPINotFound 不存在该接口
+	ErrAPINotFound = resultError(1011, "不存在该接口")
+	// ErrSignInvalid 签名格式错误或者签名未审批
+	ErrSignInvalid = resultError(1012, "签名格式错误或者签名未审批")
+	// ErrFrequencyLimit 下发短信命中了频率限制策略
+	ErrFrequencyLimit = resultError(1013, "命中频率限制策略")
+	// ErrTemplateMismatch 模板未审批或请求的内容与审核通过的模板内容不匹配
+	ErrTemplateMismatch = resultError(1014, "模板未审批或内容与模板不匹配")
+	// ErrMobileBlacklist 手机号在黑名单库中
+	ErrMobileBlacklist = resultError(1015, "手机号在黑名单库中")
+	// ErrMobileFormat 手机号格式错误
+	ErrMobileFormat = resultError(1016, "手机号格式错误")
+	// ErrContentTooLong 请求的短信内容太长
+	ErrContentTooLong = resultError(1017, "短信内容太长")
+	// ErrVoiceCodeFormat 语音验证码格式错误
+	ErrVoiceCodeFormat = resultError(1018, "语音验证码格式错误")
+	// ErrAppIDNotExist sdkappid 不存在
+	ErrAppIDNotExist = resultError(1019, "sdkappid 不存在")
+	// ErrAppIDDisabled sdkappid 已禁用
+	ErrAppIDDisabled = resultError(1020, "sdkappid 已禁用")
+	// ErrTimeInvalid 请求发起时间不正常，通常为本机时间与标准时间相差过大
+	ErrTimeInvalid = resultError(1021, "请求发起时间不正常")
+	// ErrDailyLimit 业务短信日下发条数超过设定的上限
+	ErrDailyLimit = resultError(1022, "业务短信日下发条数超过上限")
+	// ErrMobile30sLimit 单个手机号 30 秒内下发短信条数超过设定的上限
+	ErrMobile30sLimit = resultError(1023, "单个手机号 30 秒内下发条数超过上限")
+	// ErrMobileHourLimit 单个手机号 1 小时内下发短信条数超过设定的上限
+	ErrMobileHourLimit = resultError(1024, "单个手机号 1 小时内下发条数超过上限")
+	// ErrMobileDailyLimit 单个手机号日下发短信条数超过设定的上限
+	ErrMobileDailyLimit = resultError(1025, "单个手机号日下发条数超过上限")
+	// ErrMobileSameContentLimit 单个手机号下发相同内容超过设定的上限
+	ErrMobileSameContentLimit = resultError(1026, "单个手机号下发相同内容超过上限")
+	// ErrMarketingTime 营销短信发送时间限制
+	ErrMarketingTime = resultError(1029, "营销短信发送时间限制")
+	// ErrNotSupported 不支持该请求
+	ErrNotSupported = resultError(1030, "不支持该请求")
+	// ErrInsufficientBalance 套餐包余额不足
+	ErrInsufficientBalance = resultError(1031, "套餐包余额不足")
+	// ErrNoMarketingPermission 个人用户没有发营销短信的权限
+	ErrNoMarketingPermission = resultError(1032, "个人用户没有发营销短信的权限")
+	// ErrArrears 欠费被停止服务
+	ErrArrears = resultError(1033, "欠费被停止服务")
+	// ErrMixedNationcode 群发请求里既有国内手机号也有国际手机号
+	ErrMixedNationcode = resultError(1034, "群发请求里既有国内手机号也有国际手机号")
+	// ErrParamTooLong 单个模板变量字符数超过上限
+	ErrParamTooLong = resultError(1036, "单个模板变量字符数超过上限")
+	// ErrRegionNotSupported 不支持该地区短信下发
+	ErrRegionNotSupported = resultError(1045, "不支持该地区短信下发")
+	// ErrMultiTooMany 群发单次提交的手机号个数超过 200 个
+	ErrMultiTooMany = resultError(1046, "单次提交的手机号个数超过200个")
+	// ErrInternationalDailyLimit 国际短信日下发条数被限制
+	ErrInternationalDailyLimit = resultError(1047, "国际短信日下发条数被限制")
+	// ErrServerTimeout 处理请求超时
+	ErrServerTimeout = resultError(60008, "处理请求超时")
+)
+
+// decodeBodyMax DecodeError 中保留的响应内容最大长度
+const decodeBodyMax = 256
+
+// DecodeError 接口返回的内容无法解析，如返回了 HTML 错误页或字段结构发生变化
+type DecodeError struct {
+	// 请求的接口，如 sendsms
+	API string
+	// 原始响应内容
+	Body []byte
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	body := e.Body
+	if len(body) > decodeBodyMax {
+		body = body[:decodeBodyMax]
+	}
+
+	return fmt.Sprintf("%s 响应解析失败: %v: %q", e.API, e.Err, body)
+}
+
+// Unwrap 返回解析时的原始错误
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// errNoResult 响应中缺少 result 字段
+var errNoResult = errors.New("缺少 result 字段")
+
+// apiResult 各接口返回内容中共有的字段
+// 短信接口的错误信息为 errmsg，模板和签名等接口为 msg
+type apiResult struct {
+	Result *uint  `json:"result"`
+	Errmsg string `json:"errmsg"`
+	Msg    string `json:"msg"`
+	Ext    string `json:"ext"`
+}
+
+// decode 将响应解析到 v
+// 响应无法解析或缺少 result 时返回 *DecodeError，result 不为 0 时返回 *APIError
+//
+// 先解析 result，失败响应的结构可能与成功时不同，此时只尽量解析到 v，不返回 *DecodeError
+func (r *Request) decode(body []byte, v interface{}) error {
+	var res apiResult
+	if err := json.Unmarshal(body, &res); err != nil {
+		return &DecodeError{API: r.api, Body: body, Err: err}
+	}
+	if res.Result == nil {
+		return &DecodeError{API: r.api, Body: body, Err: errNoResult}
+	}
+
+	if *res.Result != SUCCESS {
+		json.Unmarshal(body, v)
+		return r.apiError(http.StatusOK, body)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return &DecodeError{API: r.api, Body: body, Err: err}
+	}
+
+	return nil
+}
+
+// call 执行请求并将响应解析到 v
+func (r *Request) call(ctx context.Context, params, v interface{}) error {
+	body, err := r.Do(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return r.decode(body, v)
+}
+
+// apiError 根据响应生成 *APIError
+func (r *Request) apiError(status int, body []byte) *APIError {
+	e := &APIError{
+		API:        r.api,
+		StatusCode: status,
+		Body:       body,
+	}
+
+	var res apiResult
+	if json.Unmarshal(body, &res) == nil {
+		if res.Result != nil {
+			e.Result = *res.Result
+		}
+		e.Errmsg = res.Errmsg
+		if e.Errmsg == "" {
+			e.Errmsg = res.Msg
+		}
+		e.Ext = res.Ext
+	}
+
+	return e
+}
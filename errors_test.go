@@ -0,0 +1,62 @@
+package qcloudsms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		result uint
+		decode bool
+	}{
+		{"success", http.StatusOK, `{"result":0,"errmsg":"OK","data":[1,2]}`, 0, false},
+		// 失败响应的结构与成功时不同，仍然返回 *APIError
+		{"failure with different shape", http.StatusOK, `{"result":1016,"errmsg":"手机号格式错误","data":"none"}`, 1016, false},
+		{"failure", http.StatusOK, `{"result":1014,"msg":"模版未审批"}`, 1014, false},
+		{"http status", http.StatusBadGateway, `bad gateway`, 0, false},
+		{"invalid json", http.StatusOK, `not json`, 0, true},
+		{"missing result", http.StatusOK, `{"errmsg":"OK"}`, 0, true},
+		{"success with wrong shape", http.StatusOK, `{"result":0,"data":"none"}`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+
+			var v struct {
+				Data []int `json:"data"`
+			}
+			err := c.NewRequest().NewSig("").NewURL(GETSIGN).call(context.Background(), struct{}{}, &v)
+
+			var apiErr *APIError
+			var decodeErr *DecodeError
+			switch {
+			case tt.decode:
+				if !errors.As(err, &decodeErr) || decodeErr.API != GETSIGN {
+					t.Errorf("err = %v, want *DecodeError", err)
+				}
+			case tt.status != http.StatusOK:
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || !errors.Is(err, ErrRequest) {
+					t.Errorf("err = %v, want *APIError with status %d", err, tt.status)
+				}
+			case tt.result != SUCCESS:
+				if !errors.As(err, &apiErr) || apiErr.Result != tt.result || apiErr.Errmsg == "" {
+					t.Errorf("err = %v, want *APIError with result %d", err, tt.result)
+				}
+			default:
+				if err != nil || len(v.Data) != 2 {
+					t.Errorf("err = %v, data = %v", err, v.Data)
+				}
+			}
+		})
+	}
+}
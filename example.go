@@ -1,6 +1,9 @@
 package qcloudsms
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -78,7 +81,11 @@ func ExampleQcloudSMS_SendSMSSingle() {
 		Tel:  SMSTel{Nationcode: "86", Mobile: "mobile"},
 	}
 
-	client.SendSMSSingle(sm)
+	res, err := client.SendSMSSingle(sm)
+	if err == nil {
+		// sid 用于关联下发状态，fee 为计费条数
+		fmt.Println(res.Sid, res.Fee)
+	}
 }
 
 func ExampleQcloudSMS_GetTemplateByPage() {
@@ -115,3 +122,110 @@ func ExampleQcloudSMSVoiceTemplateSend() {
 	}
 	client.VoiceTemplateSend(req)
 }
+
+func ExampleQcloudSMS_SendSMSBulk() {
+	opt := NewOptions(appid, appkey, sign)
+	// 同时发送的批次数
+	opt.BulkConcurrency = 8
+
+	var client = NewClient(opt)
+
+	var req = SMSMultiReq{
+		Type: 0,
+		Msg:  "短信内容",
+	}
+	for _, m := range []string{"mobile1", "mobile2", "mobile3"} {
+		req.Tel = append(req.Tel, SMSTel{Nationcode: "86", Mobile: m})
+	}
+
+	res, _ := client.SendSMSBulk(req)
+	// 只重试发送失败的号码
+	for _, d := range res.Failed() {
+		fmt.Println(d.Tel(), d.Err())
+	}
+}
+
+func ExampleStatusHandlerFunc() {
+	// 在短信控制台将回调地址配置为 http://yourhost/sms/status
+	http.Handle("/sms/status", StatusHandlerFunc(func(ctx context.Context, s SMSStatusResult) error {
+		fmt.Println(s.Sid, s.Mobile, s.ReportStatus)
+		return nil
+	}))
+}
+
+func ExampleReplyHandlerFunc() {
+	// 在短信控制台将回复回调地址配置为 http://yourhost/sms/reply
+	http.Handle("/sms/reply", ReplyHandlerFunc(func(ctx context.Context, e ReplyEvent) error {
+		fmt.Println(e.Mobile, e.Text, e.Time)
+		return nil
+	}))
+}
+
+func ExampleVoiceKeyHandlerFunc() {
+	// 根据 SendVoice 返回的 callid 关联用户的按键
+	http.Handle("/voice/key", VoiceKeyHandlerFunc(func(ctx context.Context, k VoiceKeypress) error {
+		fmt.Println(k.Callid, k.Keypress)
+		return nil
+	}))
+}
+
+func ExampleCallbackMux() {
+	// 所有推送类型使用同一个回调地址
+	http.Handle("/qcloudsms/callback", &CallbackMux{
+		Status: func(ctx context.Context, s SMSStatusResult) error {
+			fmt.Println(s.Sid, s.ReportStatus)
+			return nil
+		},
+		Reply: func(ctx context.Context, e ReplyEvent) error {
+			fmt.Println(e.Mobile, e.Text)
+			return nil
+		},
+		VoicePrompt: func(ctx context.Context, v VoiceStatus) error {
+			fmt.Println(v.Callid, v.Answered())
+			return nil
+		},
+	})
+}
+
+func ExampleStatusPoller() {
+	opt := NewOptions(appid, appkey, sign)
+	var client = NewClient(opt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 拉取到的数据先写入本地日志，处理完成前进程退出时，下次启动会重新处理
+	journal, err := OpenJournal("/var/lib/yourapp/qcloudsms.journal")
+	if err != nil {
+		return
+	}
+	defer journal.Close()
+
+	p := &StatusPoller{
+		Client:  client,
+		Journal: journal,
+		Status: func(ctx context.Context, s SMSStatusResult) error {
+			fmt.Println(s.Sid, s.ReportStatus)
+			return nil
+		},
+		Reply: func(ctx context.Context, e ReplyEvent) error {
+			fmt.Println(e.Mobile, e.Text)
+			return nil
+		},
+	}
+
+	// 直到 ctx 取消后返回
+	p.Run(ctx)
+}
+
+func ExampleSigner_Verify() {
+	signer := NewSigner(appkey)
+
+	// 网关收到的请求中的 random 在 URL 中，sig 和 time 在请求体中
+	var req SMSSingleReq
+	random := "123456"
+
+	if err := signer.Verify(req.Sig, random, req.Time, req.Tel.Mobile); err != nil {
+		fmt.Println(err)
+	}
+}
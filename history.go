@@ -0,0 +1,253 @@
+package qcloudsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HISTORYWINDOW 拉取单个手机历史记录时，每次请求的默认时间范围
+const HISTORYWINDOW = 24 * time.Hour
+
+// ErrHistoryTruncated 同一秒内的记录超过 PULLMAX 条，无法继续拆分时间范围拉取剩余的记录
+var ErrHistoryTruncated = errors.New("同一秒内的记录超过单次拉取上限，结果被截断")
+
+// HistoryQuery 拉取单个手机历史记录的条件
+type HistoryQuery struct {
+	Nationcode string
+	Mobile     string
+	// 拉取的时间范围，包含 Begin 和 End
+	Begin time.Time
+	End   time.Time
+	// 每次请求的时间范围，默认为 HISTORYWINDOW
+	Window time.Duration
+}
+
+// history 按时间窗口拉取历史记录
+type history struct {
+	ctx    context.Context
+	next   int64
+	end    int64
+	window int64
+	err    error
+
+	// fetch 拉取 [begin, end] 内的记录并保存，返回本次拉取的条数
+	fetch func(ctx context.Context, begin, end int64) (int, error)
+}
+
+func newHistory(ctx context.Context, q HistoryQuery, fetch func(ctx context.Context, begin, end int64) (int, error)) *history {
+	window := q.Window
+	if window <= 0 {
+		window = HISTORYWINDOW
+	}
+	if window < time.Second {
+		window = time.Second
+	}
+
+	return &history{
+		ctx:    ctx,
+		next:   q.Begin.Unix(),
+		end:    q.End.Unix(),
+		window: int64(window / time.Second),
+		fetch:  fetch,
+	}
+}
+
+// nextWindow 拉取下一个时间窗口，没有更多窗口或出错时返回 false
+func (h *history) nextWindow() bool {
+	if h.err != nil || h.next > h.end {
+		return false
+	}
+
+	begin := h.next
+	end := begin + h.window - 1
+	if end > h.end {
+		end = h.end
+	}
+	h.next = end + 1
+
+	h.err = h.fetchAll(begin, end)
+
+	return h.err == nil
+}
+
+// fetchAll 拉取 [begin, end] 内的记录
+// 返回条数达到 PULLMAX 时说明结果被截断，将时间范围二分后分别拉取，
+// 时间范围已经缩小到一秒仍被截断时返回 ErrHistoryTruncated
+func (h *history) fetchAll(begin, end int64) error {
+	n, err := h.fetch(h.ctx, begin, end)
+	if err != nil {
+		return err
+	}
+	if n < PULLMAX {
+		return nil
+	}
+	if begin >= end {
+		return fmt.Errorf("%w: %s", ErrHistoryTruncated, time.Unix(begin, 0).In(reportTimeZone).Format(reportTimeLayout))
+	}
+
+	mid := begin + (end-begin)/2
+	if err := h.fetchAll(begin, mid); err != nil {
+		return err
+	}
+
+	return h.fetchAll(mid+1, end)
+}
+
+// StatusIterator 按时间顺序遍历单个手机的短信下发状态
+//
+//	it := client.StatusHistory(ctx, q)
+//	for it.Next() {
+//		s := it.Status()
+//	}
+//	if err := it.Err(); err != nil {
+//		// 处理错误
+//	}
+type StatusIterator struct {
+	h    *history
+	buf  []SMSStatusResult
+	cur  SMSStatusResult
+	seen map[string]bool
+}
+
+// StatusHistory 返回单个手机在 q 时间范围内的短信下发状态
+//
+// 时间范围按 q.Window 拆分为多次请求，结果被截断时自动缩小时间范围重新拉取，
+// 结果按 sid 去重，并按用户接收时间排序
+func (c *QcloudSMS) StatusHistory(ctx context.Context, q HistoryQuery) *StatusIterator {
+	it := &StatusIterator{seen: make(map[string]bool)}
+	it.h = newHistory(ctx, q, func(ctx context.Context, begin, end int64) (int, error) {
+		res, err := c.GetStatusForMobileContext(ctx, StatusMobileReq{
+			Max:        PULLMAX,
+			BeginTime:  begin,
+			EndTime:    end,
+			Nationcode: q.Nationcode,
+			Mobile:     q.Mobile,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, s := range res.Data {
+			if it.seen[s.Sid] {
+				continue
+			}
+			it.seen[s.Sid] = true
+			it.buf = append(it.buf, s)
+		}
+
+		return len(res.Data), nil
+	})
+
+	return it
+}
+
+// Next 移动到下一条记录，没有更多记录或出错时返回 false
+// 出错前已经拉取到的记录仍会返回，之后通过 Err 取得错误
+func (it *StatusIterator) Next() bool {
+	for len(it.buf) == 0 {
+		more := it.h.nextWindow()
+		sort.SliceStable(it.buf, func(i, j int) bool {
+			return it.buf[i].UserReceiveTime.Before(it.buf[j].UserReceiveTime.Time)
+		})
+		if !more {
+			break
+		}
+	}
+	if len(it.buf) == 0 {
+		return false
+	}
+
+	it.cur = it.buf[0]
+	it.buf = it.buf[1:]
+
+	return true
+}
+
+// Status 返回当前记录
+func (it *StatusIterator) Status() SMSStatusResult {
+	return it.cur
+}
+
+// Err 返回遍历过程中出现的错误
+func (it *StatusIterator) Err() error {
+	return it.h.err
+}
+
+// ReplyIterator 按时间顺序遍历单个手机的短信回复
+type ReplyIterator struct {
+	h    *history
+	buf  []ReplyEvent
+	cur  ReplyEvent
+	seen map[string]bool
+}
+
+// ReplyHistory 返回单个手机在 q 时间范围内的短信回复
+//
+// 拆分和重新拉取的方式与 StatusHistory 相同，结果按回复时间排序。
+// 短信回复没有 sid，重新拉取时按号码、签名、回复时间、扩展码和内容去重，
+// 因此同一秒内内容完全相同的多条回复只会返回一条
+func (c *QcloudSMS) ReplyHistory(ctx context.Context, q HistoryQuery) *ReplyIterator {
+	it := &ReplyIterator{seen: make(map[string]bool)}
+	it.h = newHistory(ctx, q, func(ctx context.Context, begin, end int64) (int, error) {
+		res, err := c.GetReplyForMobileContext(ctx, StatusMobileReq{
+			Max:        PULLMAX,
+			BeginTime:  begin,
+			EndTime:    end,
+			Nationcode: q.Nationcode,
+			Mobile:     q.Mobile,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, r := range res.Data {
+			key := strings.Join([]string{r.Nationcode, r.Mobile, r.Sign, strconv.FormatInt(r.Time, 10), r.Extend, r.Text}, "|")
+			if it.seen[key] {
+				continue
+			}
+			it.seen[key] = true
+			it.buf = append(it.buf, r.Event())
+		}
+
+		return len(res.Data), nil
+	})
+
+	return it
+}
+
+// Next 移动到下一条记录，没有更多记录或出错时返回 false
+// 出错前已经拉取到的记录仍会返回，之后通过 Err 取得错误
+func (it *ReplyIterator) Next() bool {
+	for len(it.buf) == 0 {
+		more := it.h.nextWindow()
+		sort.SliceStable(it.buf, func(i, j int) bool {
+			return it.buf[i].Time.Before(it.buf[j].Time)
+		})
+		if !more {
+			break
+		}
+	}
+	if len(it.buf) == 0 {
+		return false
+	}
+
+	it.cur = it.buf[0]
+	it.buf = it.buf[1:]
+
+	return true
+}
+
+// Reply 返回当前记录
+func (it *ReplyIterator) Reply() ReplyEvent {
+	return it.cur
+}
+
+// Err 返回遍历过程中出现的错误
+func (it *ReplyIterator) Err() error {
+	return it.h.err
+}
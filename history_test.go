@@ -0,0 +1,151 @@
+package qcloudsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// historyServer 模拟拉取单个手机短信状态接口，按时间范围返回记录，最多返回 max 条
+type historyServer struct {
+	mu       sync.Mutex
+	statuses []historyStatus
+	replies  []SMSReplyResult
+	requests int
+}
+
+type historyStatus struct {
+	at     int64
+	status SMSStatusResult
+}
+
+func (s *historyServer) addStatus(sid string, at time.Time) {
+	s.statuses = append(s.statuses, historyStatus{
+		at:     at.Unix(),
+		status: SMSStatusResult{Mobile: "13800000000", Sid: sid, UserReceiveTime: receiveAt(at)},
+	})
+}
+
+func (s *historyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req statusMobileBody
+	json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	in := func(at int64) bool { return at >= req.BeginTime && at <= req.EndTime }
+
+	var data []interface{}
+	if req.Type == pullTypeStatus {
+		for _, st := range s.statuses {
+			if in(st.at) && len(data) < req.Max {
+				data = append(data, st.status)
+			}
+		}
+	} else {
+		for _, rp := range s.replies {
+			if in(rp.Time) && len(data) < req.Max {
+				data = append(data, rp)
+			}
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": 0, "errmsg": "OK", "count": len(data), "data": data})
+}
+
+// receiveAt 返回下发状态中表示 t 的用户接收时间
+func receiveAt(t time.Time) ReportTime {
+	return ReportTime{Time: t}
+}
+
+func TestStatusHistoryBisect(t *testing.T) {
+	srv := &historyServer{}
+	begin := time.Unix(1500000000, 0)
+	for i := 0; i < 1000; i++ {
+		srv.addStatus(fmt.Sprintf("sid-%d", i), begin.Add(time.Duration(999-i)*time.Minute))
+	}
+
+	it := newTestClient(t, srv).StatusHistory(context.Background(), HistoryQuery{
+		Mobile: "13800000000",
+		Begin:  begin,
+		End:    begin.Add(1000 * time.Minute),
+	})
+
+	n := 0
+	var last time.Time
+	for it.Next() {
+		s := it.Status()
+		if s.UserReceiveTime.Before(last) {
+			t.Fatalf("record %d at %v before %v", n, s.UserReceiveTime.Time, last)
+		}
+		last = s.UserReceiveTime.Time
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1000 {
+		t.Errorf("got %d records, want 1000", n)
+	}
+}
+
+func TestStatusHistoryTruncated(t *testing.T) {
+	srv := &historyServer{}
+	at := time.Unix(1500000000, 0)
+	for i := 0; i < 150; i++ {
+		srv.addStatus(fmt.Sprintf("sid-%d", i), at)
+	}
+
+	it := newTestClient(t, srv).StatusHistory(context.Background(), HistoryQuery{
+		Mobile: "13800000000",
+		Begin:  at.Add(-time.Hour),
+		End:    at.Add(time.Hour),
+	})
+
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != PULLMAX {
+		t.Errorf("got %d records, want %d", n, PULLMAX)
+	}
+	if err := it.Err(); !errors.Is(err, ErrHistoryTruncated) {
+		t.Errorf("Err() = %v, want ErrHistoryTruncated", err)
+	}
+}
+
+func TestReplyHistory(t *testing.T) {
+	srv := &historyServer{}
+	at := time.Unix(1500000000, 0)
+	for i := 0; i < 150; i++ {
+		srv.replies = append(srv.replies, SMSReplyResult{Nationcode: "86", Mobile: "13800000000", Text: "1", Sign: "签名", Time: at.Unix() + int64(i)})
+	}
+	// 同一秒内签名不同的相同回复
+	srv.replies = append(srv.replies, SMSReplyResult{Nationcode: "86", Mobile: "13800000000", Text: "1", Sign: "其他签名", Time: at.Unix()})
+
+	it := newTestClient(t, srv).ReplyHistory(context.Background(), HistoryQuery{
+		Mobile: "13800000000",
+		Begin:  at,
+		End:    at.Add(time.Hour),
+	})
+
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 151 {
+		t.Errorf("got %d replies, want 151", n)
+	}
+	if srv.requests < 3 {
+		t.Errorf("made %d requests, want the truncated window split", srv.requests)
+	}
+}
@@ -0,0 +1,282 @@
+package qcloudsms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// 日志中记录的数据类型
+const (
+	// JournalStatus 短信下发状态，Data 为 []SMSStatusResult
+	JournalStatus = "status"
+	// JournalReply 短信回复，Data 为 []SMSReplyResult
+	JournalReply = "reply"
+)
+
+// journalCompactMin 日志中的记录数超过此值，且超过未确认记录数的 2 倍时自动压缩
+const journalCompactMin = 1024
+
+// JournalEntry 日志中的一条记录
+type JournalEntry struct {
+	Seq uint64 `json:"seq"`
+	// 数据类型，JournalStatus 或 JournalReply
+	Kind string          `json:"kind,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+	// 为 true 时表示确认 Seq 对应的记录已处理完成
+	Ack bool `json:"ack,omitempty"`
+}
+
+// Journal 只追加写入的本地日志，用于保存拉取到的数据
+//
+// 拉取到的数据在交给处理函数之前先写入日志并 fsync，处理完成后确认；
+// 进程重启后可以通过 Pending 取得未确认的记录重新处理，实现至少一次的处理语义
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	seq     uint64
+	records int
+	pending map[uint64]JournalEntry
+}
+
+// OpenJournal 打开或创建 path 对应的日志文件，并读取其中未确认的记录
+// 文件末尾不完整的记录（如写入时进程退出）会被丢弃
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{
+		path:    path,
+		pending: make(map[uint64]JournalEntry),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	created := os.IsNotExist(err)
+
+	valid, err := j.load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if valid < len(data) {
+		if err := f.Truncate(int64(valid)); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if created {
+		// 新创建的文件，同步目录使文件本身在断电后仍然存在
+		if err := syncDir(path); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	j.f = f
+
+	return j, nil
+}
+
+// syncDir 对 path 所在的目录执行 fsync，使创建、重命名等操作持久化
+// Windows 不支持对目录 fsync，直接返回
+func syncDir(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// load 读取日志内容，返回完整记录的长度
+func (j *Journal) load(data []byte) (int, error) {
+	valid := 0
+	for valid < len(data) {
+		i := bytes.IndexByte(data[valid:], '\n')
+		if i < 0 {
+			// 最后一条记录不完整
+			break
+		}
+		line := data[valid : valid+i]
+
+		var e JournalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			if valid+i+1 == len(data) {
+				break
+			}
+			return 0, fmt.Errorf("日志 %s 解析失败: %v", j.path, err)
+		}
+
+		j.apply(e)
+		valid += i + 1
+	}
+
+	return valid, nil
+}
+
+// apply 根据记录更新未确认的记录
+func (j *Journal) apply(e JournalEntry) {
+	j.records++
+	if e.Seq > j.seq {
+		j.seq = e.Seq
+	}
+
+	if e.Ack {
+		delete(j.pending, e.Seq)
+	} else {
+		j.pending[e.Seq] = e
+	}
+}
+
+// write 写入一条记录并 fsync
+func (j *Journal) write(e JournalEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return j.f.Sync()
+}
+
+// Append 写入一批数据，返回记录的序号，用于处理完成后调用 Ack
+func (j *Journal) Append(kind string, v interface{}) (uint64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e := JournalEntry{Seq: j.seq + 1, Kind: kind, Data: data}
+	if err := j.write(e); err != nil {
+		return 0, err
+	}
+	j.apply(e)
+
+	return e.Seq, nil
+}
+
+// Ack 确认 seq 对应的记录已处理完成
+func (j *Journal) Ack(seq uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.pending[seq]; !ok {
+		return nil
+	}
+
+	e := JournalEntry{Seq: seq, Ack: true}
+	if err := j.write(e); err != nil {
+		return err
+	}
+	j.apply(e)
+
+	// 未确认的记录会在压缩时保留，只要已确认的记录占多数就压缩
+	if j.records >= journalCompactMin && j.records > 2*len(j.pending) {
+		return j.compact()
+	}
+
+	return nil
+}
+
+// Pending 返回未确认的记录，按序号排列
+func (j *Journal) Pending() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	es := make([]JournalEntry, 0, len(j.pending))
+	for _, e := range j.pending {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(a, b int) bool { return es[a].Seq < es[b].Seq })
+
+	return es
+}
+
+// Compact 重写日志文件，只保留未确认的记录
+func (j *Journal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.compact()
+}
+
+// compact 将未确认的记录写入临时文件，再替换日志文件
+// 临时文件以追加方式打开，替换后直接作为新的日志文件继续写入，不需要重新打开
+func (j *Journal) compact() error {
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	es := make([]JournalEntry, 0, len(j.pending))
+	for _, e := range j.pending {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(a, b int) bool { return es[a].Seq < es[b].Seq })
+
+	var buf bytes.Buffer
+	for _, e := range es {
+		line, err := json.Marshal(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	// 保留最大序号，避免重新打开后序号重复
+	if _, ok := j.pending[j.seq]; !ok && j.seq > 0 {
+		line, _ := json.Marshal(JournalEntry{Seq: j.seq, Ack: true})
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := os.Rename(tmp, j.path); err != nil {
+		f.Close()
+		return err
+	}
+	j.f.Close()
+	j.f = f
+	j.records = len(es)
+
+	return syncDir(j.path)
+}
+
+// Close 关闭日志文件
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.f.Close()
+}
@@ -0,0 +1,104 @@
+package qcloudsms
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalCompactsWithPending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	data := []SMSStatusResult{{Mobile: "13800000000", Sid: "sid"}}
+
+	poison, err := j.Append(JournalStatus, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3000; i++ {
+		seq, err := j.Append(JournalStatus, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.Ack(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if j.records >= journalCompactMin {
+		t.Errorf("records = %d, want < %d after compaction", j.records, journalCompactMin)
+	}
+
+	pending := j.Pending()
+	if len(pending) != 1 || pending[0].Seq != poison {
+		t.Fatalf("Pending() = %+v, want only seq %d", pending, poison)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > 100*1024 {
+		t.Errorf("journal size = %d, want compacted", fi.Size())
+	}
+
+	// 压缩后写入的记录应在替换后的文件中
+	last, err := j.Append(JournalStatus, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending = j.Pending()
+	if len(pending) != 2 || pending[0].Seq != poison || pending[1].Seq != last {
+		t.Errorf("after reopen: Pending() = %+v, want seq %d and %d", pending, poison, last)
+	}
+}
+
+func TestJournalReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := j.Append(JournalStatus, []SMSStatusResult{{Sid: "a"}})
+	b, _ := j.Append(JournalReply, []SMSReplyResult{{Text: "b"}})
+	if err := j.Ack(a); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// 模拟写入时进程退出留下的不完整记录
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"seq":3,"kind":"sta`)
+	f.Close()
+
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	pending := j.Pending()
+	if len(pending) != 1 || pending[0].Seq != b || pending[0].Kind != JournalReply {
+		t.Fatalf("Pending() = %+v, want only seq %d", pending, b)
+	}
+
+	c, err := j.Append(JournalStatus, []SMSStatusResult{{Sid: "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != b+1 {
+		t.Errorf("next seq = %d, want %d", c, b+1)
+	}
+}
@@ -0,0 +1,115 @@
+package qcloudsms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+)
+
+// ErrUnknownCallback 无法识别推送类型，或没有设置对应的处理函数
+var ErrUnknownCallback = errors.New("无法处理的推送")
+
+// CallbackMux 根据推送内容识别推送类型，交给对应的处理函数
+//
+// 所有类型的推送都可以配置为同一个回调地址：
+// 数组为短信下发状态，包含 text 的对象为短信回复，语音推送根据外层字段区分
+type CallbackMux struct {
+	Status       StatusHandlerFunc
+	Reply        ReplyHandlerFunc
+	VoiceCode    VoiceCodeHandlerFunc
+	VoicePrompt  VoicePromptHandlerFunc
+	VoiceKey     VoiceKeyHandlerFunc
+	VoiceFailure VoiceFailureHandlerFunc
+
+	// 处理无法识别或没有设置处理函数的推送，body 为原始推送内容
+	// 返回 nil 表示推送已保存，平台不再重复推送，因此应在持久化 body 之后再返回 nil
+	//
+	// 为空时记录日志并应答失败（ErrUnknownCallback），由平台重新推送，推送内容不会丢失
+	Fallback func(ctx context.Context, body []byte) error
+
+	// 没有设置 Fallback 时记录无法处理的推送，为空时输出到标准错误
+	Logger *log.Logger
+}
+
+// ServeHTTP 识别推送类型并处理
+func (m *CallbackMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveCallback(w, r, m.handle)
+}
+
+// handle 识别推送类型，交给对应的处理函数
+func (m *CallbackMux) handle(ctx context.Context, body []byte) error {
+	if h := m.route(body); h != nil {
+		return h(ctx, body)
+	}
+
+	return m.fallback(ctx, body)
+}
+
+// route 返回推送内容对应的处理函数，无法识别或未设置时返回 nil
+func (m *CallbackMux) route(body []byte) func(ctx context.Context, body []byte) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	if trimmed[0] == '[' {
+		if m.Status != nil {
+			return m.Status.handle
+		}
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return nil
+	}
+
+	switch {
+	case has(fields, voiceCodeKey):
+		if m.VoiceCode != nil {
+			return m.VoiceCode.handle
+		}
+	case has(fields, voicePromptKey):
+		if m.VoicePrompt != nil {
+			return m.VoicePrompt.handle
+		}
+	case has(fields, voiceKeyKey):
+		if m.VoiceKey != nil {
+			return m.VoiceKey.handle
+		}
+	case has(fields, voiceFailureKey):
+		if m.VoiceFailure != nil {
+			return m.VoiceFailure.handle
+		}
+	case has(fields, "text") && has(fields, "mobile"):
+		if m.Reply != nil {
+			return m.Reply.handle
+		}
+	}
+
+	return nil
+}
+
+// fallback 处理无法识别的推送，没有设置 Fallback 时返回 ErrUnknownCallback，使平台重新推送
+func (m *CallbackMux) fallback(ctx context.Context, body []byte) error {
+	if m.Fallback != nil {
+		return m.Fallback(ctx, body)
+	}
+
+	logger := m.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "["+SDKName+"]", log.LstdFlags)
+	}
+	logger.Printf("Unknown Callback : %s\n", string(body))
+
+	return ErrUnknownCallback
+}
+
+func has(fields map[string]json.RawMessage, key string) bool {
+	_, ok := fields[key]
+	return ok
+}
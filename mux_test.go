@@ -0,0 +1,63 @@
+package qcloudsms
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"testing"
+)
+
+func TestCallbackMux(t *testing.T) {
+	rec := &recorder{}
+	var logs bytes.Buffer
+	m := &CallbackMux{
+		Status: func(ctx context.Context, s SMSStatusResult) error {
+			return rec.record("status "+s.Sid, s.Errmsg == "fail")
+		},
+		Reply: func(ctx context.Context, e ReplyEvent) error {
+			return rec.record("reply "+e.Text, e.Text == "fail")
+		},
+		VoiceCode: func(ctx context.Context, v VoiceStatus) error {
+			return rec.record("voicecode "+v.Callid, false)
+		},
+		VoicePrompt: func(ctx context.Context, v VoiceStatus) error {
+			return rec.record("voiceprompt "+v.Callid, false)
+		},
+		VoiceKey: func(ctx context.Context, k VoiceKeypress) error {
+			return rec.record("voicekey "+k.Keypress, false)
+		},
+		VoiceFailure: func(ctx context.Context, f VoiceFailure) error {
+			return rec.record("voicefailure "+f.FailureReason, false)
+		},
+		Logger: log.New(&logs, "", 0),
+	}
+
+	// 已知类型没有设置处理函数时交给 Fallback
+	fallback := &CallbackMux{
+		Status: m.Status,
+		Fallback: func(ctx context.Context, body []byte) error {
+			return rec.record("fallback "+string(body), false)
+		},
+	}
+
+	runCallbackTests(t, rec, []callbackTest{
+		{name: "status", handler: m, body: ` [{"sid":"a"},{"sid":"b"}]`, want: "\"status a\"\n\"status b\""},
+		{name: "reply", handler: m, body: `{"mobile":"13800000000","text":"TD"}`, want: `"reply TD"`},
+		{name: "voicecode", handler: m, body: `{"voicecode_callback":{"callid":"c1"}}`, want: `"voicecode c1"`},
+		{name: "voiceprompt", handler: m, body: `{"voiceprompt_callback":{"callid":"c2"}}`, want: `"voiceprompt c2"`},
+		{name: "voicekey", handler: m, body: `{"voicekey_callback":{"keypress":"1"}}`, want: `"voicekey 1"`},
+		{name: "voice failure", handler: m, body: `{"voice_failure_callback":{"failure_reason":"关机"}}`, want: `"voicefailure 关机"`},
+		{name: "handler error", handler: m, body: `[{"sid":"a","errmsg":"fail"}]`, result: 1},
+		{name: "unknown without fallback", handler: m, body: `not json`, result: 1},
+		{name: "empty without fallback", handler: m, body: ``, result: 1},
+		{name: "GET", handler: m, method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "nil handler to fallback", handler: fallback, body: `{"voicekey_callback":{"keypress":"1"}}`, want: `"fallback {\"voicekey_callback\":{\"keypress\":\"1\"}}"`},
+		{name: "unknown to fallback", handler: fallback, body: `not json`, want: `"fallback not json"`},
+		{name: "status with fallback", handler: fallback, body: `[{"sid":"a"}]`, want: `"status a"`},
+	})
+
+	if !bytes.Contains(logs.Bytes(), []byte("not json")) {
+		t.Errorf("log = %q, want the unknown payload", logs.String())
+	}
+}
@@ -0,0 +1,171 @@
+package qcloudsms
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// NONCEWINDOW 默认随机数去重的时间窗口
+const NONCEWINDOW = 10 * time.Minute
+
+// RANDOMLENMIN 请求随机数的最小长度，Options.RandomLen 小于此值时使用此值
+const RANDOMLENMIN = 6
+
+// nonceRetry 生成重复随机数时的最大重试次数
+const nonceRetry = 32
+
+// nonceSeenMax 去重时最多记录的随机数数量，超出时淘汰最早的记录
+const nonceSeenMax = 1 << 16
+
+// NonceSource 为请求生成随机数 random，l 为随机数长度
+// 可在测试中替换为返回固定值的实现，使请求 URL 和签名可复现
+type NonceSource interface {
+	Nonce(l int) (string, error)
+}
+
+// NonceFunc 将普通函数适配为 NonceSource
+type NonceFunc func(l int) (string, error)
+
+// Nonce 调用 f(l)
+func (f NonceFunc) Nonce(l int) (string, error) {
+	return f(l)
+}
+
+// cryptoNonce 使用 crypto/rand 生成数字随机数，并保证与时间窗口内最近生成的随机数不重复
+type cryptoNonce struct {
+	seen  *recentSet
+	clock Clock
+}
+
+// NewCryptoNonce 返回一个基于 crypto/rand 的 NonceSource
+//
+// 同一个 NonceSource 在 window 时间内不返回重复的随机数。
+// 窗口内最多记录 nonceSeenMax 个随机数，请求过多时淘汰最早的记录，
+// 保证与最近生成的随机数不重复，不会使请求失败
+func NewCryptoNonce(window time.Duration) NonceSource {
+	return newCryptoNonce(window, wallClock{})
+}
+
+func newCryptoNonce(window time.Duration, clock Clock) *cryptoNonce {
+	return &cryptoNonce{seen: newRecentSet(window), clock: clock}
+}
+
+// Nonce 生成长度为 l 的数字随机数
+//
+// 记录的随机数最多为可取值数量的一半和 nonceSeenMax 中较小者，
+// 因此每次生成不重复的概率不低于一半，重试 nonceRetry 次仍然重复时返回最后生成的随机数
+func (n *cryptoNonce) Nonce(l int) (string, error) {
+	if l < 1 {
+		l = 1
+	}
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(l)), nil)
+
+	limit := nonceSeenMax
+	if half := new(big.Int).Rsh(max, 1); half.IsInt64() && half.Int64() < int64(limit) {
+		limit = int(half.Int64())
+	}
+
+	now := n.clock.Now()
+
+	var s string
+	for i := 0; i < nonceRetry; i++ {
+		v, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		s = fmt.Sprintf("%0*d", l, v)
+		if n.seen.add(s, now, limit) {
+			return s, nil
+		}
+	}
+
+	return s, nil
+}
+
+// recentSet 记录时间窗口内出现过的值，用于检查重复
+type recentSet struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+	queue  []recentEntry
+}
+
+type recentEntry struct {
+	v string
+	t time.Time
+}
+
+func newRecentSet(window time.Duration) *recentSet {
+	return &recentSet{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// add 记录 v，若 v 在时间窗口内已经出现过则返回 false
+// max 大于 0 时最多记录 max 个值，超出时淘汰最早的记录
+func (s *recentSet) add(v string, now time.Time, max int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+
+	if _, ok := s.seen[v]; ok {
+		return false
+	}
+
+	if max > 0 {
+		for len(s.seen) >= max && len(s.queue) > 0 {
+			s.remove(s.queue[0])
+			s.queue = s.queue[1:]
+		}
+	}
+
+	s.seen[v] = now
+	s.queue = append(s.queue, recentEntry{v: v, t: now})
+
+	return true
+}
+
+// setWindow 修改时间窗口
+func (s *recentSet) setWindow(window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.window = window
+}
+
+// len 返回时间窗口内记录的值的数量
+func (s *recentSet) len(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+
+	return len(s.seen)
+}
+
+// expire 删除超出时间窗口的值
+func (s *recentSet) expire(now time.Time) {
+	i := 0
+	for ; i < len(s.queue); i++ {
+		e := s.queue[i]
+		if now.Sub(e.t) < s.window {
+			break
+		}
+		s.remove(e)
+	}
+
+	s.queue = s.queue[i:]
+}
+
+// remove 删除队列中的记录 e 对应的值
+func (s *recentSet) remove(e recentEntry) {
+	if t, ok := s.seen[e.v]; ok && t.Equal(e.t) {
+		delete(s.seen, e.v)
+	}
+}
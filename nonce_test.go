@@ -0,0 +1,89 @@
+package qcloudsms
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedClock struct{ t time.Time }
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+func TestCryptoNonceEvicts(t *testing.T) {
+	n := newCryptoNonce(NONCEWINDOW, fixedClock{time.Unix(1500000000, 0)})
+
+	var recent []string
+	for i := 0; i < 100; i++ {
+		v, err := n.Nonce(1)
+		if err != nil {
+			t.Fatalf("Nonce(1) #%d: %v", i, err)
+		}
+		if len(v) != 1 {
+			t.Fatalf("Nonce(1) = %q, want 1 digit", v)
+		}
+
+		// 1 位随机数最多记录 5 个，与最近的 4 个不重复
+		for _, r := range recent {
+			if r == v {
+				t.Fatalf("Nonce(1) #%d = %s, repeats one of %v", i, v, recent)
+			}
+		}
+		recent = append(recent, v)
+		if len(recent) > 4 {
+			recent = recent[1:]
+		}
+	}
+
+	if got := n.seen.len(time.Unix(1500000000, 0)); got != 5 {
+		t.Errorf("recorded %d nonces, want 5", got)
+	}
+}
+
+func TestRecentSetMax(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	s := newRecentSet(time.Minute)
+	for _, v := range []string{"a", "b", "c", "d"} {
+		if !s.add(v, now, 3) {
+			t.Fatalf("add(%s) = false", v)
+		}
+	}
+
+	if s.add("d", now, 3) || s.add("b", now, 3) {
+		t.Error("recent values were accepted again")
+	}
+	if !s.add("a", now, 3) {
+		t.Error("evicted value a was rejected")
+	}
+	if got := s.len(now); got != 3 {
+		t.Errorf("len = %d, want 3", got)
+	}
+}
+
+func TestCryptoNonceUsesClock(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	n := newCryptoNonce(time.Minute, fixedClock{now})
+	for i := 0; i < 5; i++ {
+		n.Nonce(1)
+	}
+
+	if got := n.seen.len(now.Add(time.Minute)); got != 0 {
+		t.Errorf("after window: recorded %d nonces, want 0", got)
+	}
+}
+
+func TestNewClientRandomLen(t *testing.T) {
+	c := NewClient(&Options{APPID: "1400", APPKEY: "key"})
+	if c.Options.RandomLen != RANDOMLENMIN {
+		t.Fatalf("RandomLen = %d, want %d", c.Options.RandomLen, RANDOMLENMIN)
+	}
+
+	for i := 0; i < 20; i++ {
+		r := c.NewRequest()
+		if r.err != nil {
+			t.Fatalf("NewRequest #%d: %v", i, r.err)
+		}
+		if len(r.Random) != RANDOMLENMIN {
+			t.Fatalf("Random = %q, want %d digits", r.Random, RANDOMLENMIN)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package qcloudsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// POLLINTERVAL StatusPoller 没有数据时默认的等待时间
+	POLLINTERVAL = 5 * time.Second
+	// POLLMAXINTERVAL StatusPoller 连续没有数据时默认的最长等待时间
+	POLLMAXINTERVAL = time.Minute
+)
+
+// ErrPollerNoHandler StatusPoller 的 Status 和 Reply 都没有设置
+var ErrPollerNoHandler = errors.New("StatusPoller 没有设置 Status 或 Reply")
+
+// StatusPoller 持续拉取短信下发状态和短信回复
+//
+// 每轮拉取一次下发状态和短信回复，返回条数等于 Max 时立即继续拉取，
+// 没有数据时等待 Interval，连续没有数据时等待时间加倍，直到 MaxInterval
+type StatusPoller struct {
+	// 拉取数据的客户端，通常为 *QcloudSMS，测试时可以替换为 qcloudsmstest.Mock
+	Client StatusPuller
+
+	// 下发状态的处理函数，为空时不拉取下发状态
+	Status StatusHandlerFunc
+	// 短信回复的处理函数，为空时不拉取短信回复
+	Reply ReplyHandlerFunc
+
+	// 单次拉取的最大条数，默认为 PULLMAX
+	Max int
+	// 没有数据时的等待时间，默认为 POLLINTERVAL
+	Interval time.Duration
+	// 连续没有数据时的最长等待时间，默认为 POLLMAXINTERVAL
+	MaxInterval time.Duration
+
+	// 拉取到的数据在处理前写入的日志，为空时不记录
+	// 设置后，处理函数出错的数据会在每次等待后重新处理，直到全部处理成功，
+	// 进程退出时未确认的数据会在下次 Run 开始时重新处理。
+	// 写入日志失败的数据暂存在内存中，每次等待后重试写入，写入成功后才交给处理函数，
+	// 因此这部分数据在进程退出时会丢失
+	Journal *Journal
+
+	// 拉取或处理出错时调用，为空时使用 Logger 记录
+	// 拉取过的数据不会再次返回，处理函数出错时仍会继续处理后续数据
+	OnError func(err error)
+
+	// 没有设置 OnError 时记录错误的日志，为空时输出到标准错误
+	Logger *log.Logger
+
+	// 写入 Journal 失败、等待重试写入的数据
+	unjournaled []journalBatch
+}
+
+// journalBatch 一批等待写入 Journal 的数据
+type journalBatch struct {
+	kind string
+	data interface{}
+}
+
+// Run 开始拉取，直到 ctx 取消，返回 ctx.Err()
+// 设置了 Journal 时，先重新处理日志中未确认的记录，之后每次等待后重试处理失败的记录
+// Status 和 Reply 都没有设置时立即返回 ErrPollerNoHandler
+func (p *StatusPoller) Run(ctx context.Context) error {
+	if p.Status == nil && p.Reply == nil {
+		return ErrPollerNoHandler
+	}
+
+	if p.Journal != nil {
+		p.replay(ctx)
+	}
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = POLLINTERVAL
+	}
+	maxInterval := p.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = POLLMAXINTERVAL
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+
+	wait := interval
+	for {
+		n, full, err := p.poll(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			p.report(err)
+		}
+
+		switch {
+		case full && err == nil:
+			// 还有数据未拉取，立即继续
+			wait = interval
+			continue
+		case n > 0:
+			wait = interval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if p.Journal != nil {
+			p.replay(ctx)
+		}
+
+		if n == 0 {
+			wait *= 2
+			if wait > maxInterval {
+				wait = maxInterval
+			}
+		}
+	}
+}
+
+// poll 拉取一次下发状态和短信回复
+// 返回拉取到的总条数，以及是否有类型的返回条数达到 Max
+func (p *StatusPoller) poll(ctx context.Context) (n int, full bool, err error) {
+	max := p.Max
+	if max <= 0 || max > PULLMAX {
+		max = PULLMAX
+	}
+
+	if p.Status != nil {
+		res, e := p.Client.PullStatusContext(ctx, PullStatusReq{Max: max})
+		if e != nil {
+			err = e
+		} else if len(res.Data) > 0 {
+			n += len(res.Data)
+			full = full || len(res.Data) >= max
+			p.dispatch(ctx, JournalStatus, res.Data)
+		}
+	}
+
+	if p.Reply != nil {
+		res, e := p.Client.PullReplyContext(ctx, PullStatusReq{Max: max})
+		if e != nil {
+			err = e
+		} else if len(res.Data) > 0 {
+			n += len(res.Data)
+			full = full || len(res.Data) >= max
+			p.dispatch(ctx, JournalReply, res.Data)
+		}
+	}
+
+	return n, full, err
+}
+
+// dispatch 将拉取到的一批数据交给处理函数
+// 设置了 Journal 时，处理前先写入日志，全部处理成功后确认；
+// 写入失败时暂存在内存中，由 replay 重试写入后再处理
+func (p *StatusPoller) dispatch(ctx context.Context, kind string, data interface{}) {
+	if p.Journal == nil {
+		p.handle(ctx, data)
+		return
+	}
+
+	seq, err := p.Journal.Append(kind, data)
+	if err != nil {
+		p.report(err)
+		p.unjournaled = append(p.unjournaled, journalBatch{kind: kind, data: data})
+		return
+	}
+
+	if !p.handle(ctx, data) {
+		return
+	}
+	if err := p.Journal.Ack(seq); err != nil {
+		p.report(err)
+	}
+}
+
+// handle 逐条处理一批数据，全部处理成功时返回 true
+func (p *StatusPoller) handle(ctx context.Context, data interface{}) bool {
+	ok := true
+
+	switch d := data.(type) {
+	case []SMSStatusResult:
+		for _, s := range d {
+			if err := p.Status(ctx, s); err != nil {
+				p.report(err)
+				ok = false
+			}
+		}
+	case []SMSReplyResult:
+		for _, r := range d {
+			if err := p.Reply(ctx, r.Event()); err != nil {
+				p.report(err)
+				ok = false
+			}
+		}
+	}
+
+	return ok
+}
+
+// replay 重试写入暂存在内存中的数据，并重新处理日志中未确认的记录
+func (p *StatusPoller) replay(ctx context.Context) {
+	batches := p.unjournaled
+	p.unjournaled = nil
+	for i, b := range batches {
+		if _, err := p.Journal.Append(b.kind, b.data); err != nil {
+			p.report(err)
+			p.unjournaled = append(p.unjournaled, batches[i:]...)
+			break
+		}
+	}
+
+	for _, e := range p.Journal.Pending() {
+		var data interface{}
+		switch {
+		case e.Kind == JournalStatus && p.Status != nil:
+			var ss []SMSStatusResult
+			if err := json.Unmarshal(e.Data, &ss); err != nil {
+				p.report(err)
+				continue
+			}
+			data = ss
+		case e.Kind == JournalReply && p.Reply != nil:
+			var rs []SMSReplyResult
+			if err := json.Unmarshal(e.Data, &rs); err != nil {
+				p.report(err)
+				continue
+			}
+			data = rs
+		default:
+			continue
+		}
+
+		if !p.handle(ctx, data) {
+			continue
+		}
+		if err := p.Journal.Ack(e.Seq); err != nil {
+			p.report(err)
+		}
+	}
+}
+
+// report 报告拉取或处理时的错误
+func (p *StatusPoller) report(err error) {
+	if p.OnError != nil {
+		p.OnError(err)
+		return
+	}
+
+	logger := p.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "["+SDKName+"]", log.LstdFlags)
+	}
+	logger.Printf("Poll Error : %v\n", err)
+}
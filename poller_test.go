@@ -0,0 +1,199 @@
+package qcloudsms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pullServer 模拟拉取短信状态接口，拉取过的数据不再返回
+type pullServer struct {
+	mu       sync.Mutex
+	statuses []SMSStatusResult
+	replies  []SMSReplyResult
+	// 前 fail 次请求返回错误码
+	fail  int
+	pulls int
+}
+
+func (s *pullServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req pullStatusBody
+	json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pulls++
+	if s.fail > 0 {
+		s.fail--
+		w.Write([]byte(`{"result":1023,"errmsg":"频率限制"}`))
+		return
+	}
+
+	var data interface{}
+	if req.Type == 0 {
+		n := len(s.statuses)
+		if n > req.Max {
+			n = req.Max
+		}
+		data, s.statuses = s.statuses[:n], s.statuses[n:]
+	} else {
+		n := len(s.replies)
+		if n > req.Max {
+			n = req.Max
+		}
+		data, s.replies = s.replies[:n], s.replies[n:]
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": 0, "errmsg": "OK", "data": data})
+}
+
+func TestStatusPoller(t *testing.T) {
+	srv := &pullServer{fail: 1}
+	for _, sid := range []string{"a", "b", "c"} {
+		srv.statuses = append(srv.statuses, SMSStatusResult{Mobile: "13800000000", Sid: sid})
+	}
+	srv.replies = []SMSReplyResult{{Mobile: "13800000000", Text: "TD"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var got []string
+	done := func(v string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		got = append(got, v)
+		if len(got) == 4 {
+			cancel()
+		}
+	}
+
+	var logs bytes.Buffer
+	p := &StatusPoller{
+		Client: newTestClient(t, srv),
+		Status: func(ctx context.Context, s SMSStatusResult) error {
+			done(s.Sid)
+			return nil
+		},
+		Reply: func(ctx context.Context, e ReplyEvent) error {
+			done(e.Text)
+			return nil
+		},
+		Max:      2,
+		Interval: time.Millisecond,
+		Logger:   log.New(&logs, "", 0),
+	}
+	if err := p.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	if len(got) != 4 {
+		t.Errorf("handled %v, want a, b, c and TD", got)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("Poll Error")) {
+		t.Errorf("log = %q, want the pull error", logs.String())
+	}
+}
+
+func TestStatusPollerNoHandler(t *testing.T) {
+	p := &StatusPoller{Client: NewClient(NewOptions("1400", "key", "sign"))}
+
+	if err := p.Run(context.Background()); err != ErrPollerNoHandler {
+		t.Errorf("Run = %v, want ErrPollerNoHandler", err)
+	}
+}
+
+func TestStatusPollerRetriesFailedBatch(t *testing.T) {
+	srv := &pullServer{statuses: []SMSStatusResult{{Mobile: "13800000000", Sid: "a"}}}
+
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attempts := 0
+	p := &StatusPoller{
+		Client: newTestClient(t, srv),
+		Status: func(ctx context.Context, s SMSStatusResult) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("temporary")
+			}
+			cancel()
+			return nil
+		},
+		Interval: 10 * time.Millisecond,
+		Journal:  journal,
+		OnError:  func(err error) {},
+	}
+	p.Run(ctx)
+
+	if attempts != 3 {
+		t.Fatalf("handler called %d times, want 3", attempts)
+	}
+	if pending := journal.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() = %+v, want empty", pending)
+	}
+}
+
+func TestStatusPollerRetriesAppend(t *testing.T) {
+	srv := &pullServer{statuses: []SMSStatusResult{{Mobile: "13800000000", Sid: "a"}}}
+
+	path := filepath.Join(t.TempDir(), "journal")
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	// 用已关闭的文件使第一次写入失败
+	good := journal.f
+	closed, _ := os.Open(path)
+	closed.Close()
+	journal.f = closed
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var errs []error
+	var handled []string
+	p := &StatusPoller{
+		Client: newTestClient(t, srv),
+		Status: func(ctx context.Context, s SMSStatusResult) error {
+			if len(journal.Pending()) != 1 {
+				t.Error("handler called before the batch was journaled")
+			}
+			handled = append(handled, s.Sid)
+			cancel()
+			return nil
+		},
+		Interval: 10 * time.Millisecond,
+		Journal:  journal,
+		OnError: func(err error) {
+			errs = append(errs, err)
+			journal.f = good
+		},
+	}
+	p.Run(ctx)
+
+	if len(errs) != 1 || len(handled) != 1 || handled[0] != "a" {
+		t.Fatalf("errors %v, handled %v, want one append error and a", errs, handled)
+	}
+	if pending := journal.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() = %+v, want empty", pending)
+	}
+}
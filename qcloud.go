@@ -5,25 +5,22 @@ package qcloudsms
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math/rand"
+	"net"
 	"net/http"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 )
 
-// QcloudClient 用来构造请求，设置各项参数，和执行请求的接口
+// QcloudClient 用来构造请求，设置各项参数的接口
 type QcloudClient interface {
-	NewRandom(l int) *QcloudSMS
-	NewSig(m string) *QcloudSMS
-	NewUrl(api string) *QcloudSMS
-	NewRequest(params interface{}) ([]byte, error)
+	NewRequest() *Request
 
 	SetAPPID(appid string) *QcloudSMS
 	SetAPPKEY(appkey string) *QcloudSMS
@@ -31,15 +28,29 @@ type QcloudClient interface {
 	SetLogger(logger *log.Logger) *QcloudSMS
 }
 
-// QcloudSMS 是请求的结构
-// 一次请求具体功能由 QcloudClient 接口实现
+// QcloudSMS 是 client 的结构，保存各项参数
+// 单次请求的随机数、签名等由 NewRequest 生成的 Request 单独保存，
+// 因此同一个 QcloudSMS 可以在多个 goroutine 中并发使用
 type QcloudSMS struct {
+	Options Options
+	Logger  *log.Logger
+
+	httpClient *http.Client
+	transport  http.RoundTripper
+	signer     *Signer
+}
+
+// Request 是一次请求的结构，保存本次请求的随机数、签名、URL 和请求时间
+// 每次调用 API 时通过 NewRequest 生成，不在请求之间共享
+type Request struct {
 	Random  string
 	Sig     string
 	URL     string
 	ReqTime int64
-	Options Options
-	Logger  *log.Logger
+
+	c   *QcloudSMS
+	api string
+	err error
 }
 
 // Options 用来构造请求的参数结构
@@ -51,18 +62,51 @@ type Options struct {
 	// 表示短信签名
 	SIGN string
 
-	// 请求随机数长度
+	// 请求随机数长度，不小于 RANDOMLENMIN
 	RandomLen int
+	// 请求随机数来源，默认使用 crypto/rand 生成，并以 Clock 的时间在 NONCEWINDOW 内去重
+	Nonce     NonceSource
 	UserAgent string
 
 	HTTP struct {
 		Timeout time.Duration
+		// 自定义 http.Client，设置后 Timeout 和 Transport 不再生效
+		Client *http.Client
+		// 自定义 http.RoundTripper，可用于设置代理、TLS 根证书或包装请求
+		// 为空时每个 client 使用一个独立的、开启长连接的 http.Transport
+		Transport http.RoundTripper
 	}
 
+	// 批量群发短信的并发请求数，默认为 BULKCONCURRENCY
+	BulkConcurrency int
+
+	// 请求时间来源，每次请求时读取当前时间，默认为系统时间
+	Clock Clock
+
+	// 接口的基本 URL，默认为 SVR，可设置为本地测试服务器或代理的地址
+	BaseURL string
+	// 按模板发送语音接口的基本 URL，默认为 VSVR
+	VoiceBaseURL string
+	// 各接口相对于基本 URL 的路径，key 为接口名称，如 SENDSMS
+	// 未设置的接口使用默认路径，如 tlssmssvr/sendsms
+	Paths map[string]string
+
 	// 是否开启Debug
 	Debug bool
 }
 
+// Clock 为请求提供当前时间，可在测试中替换为固定或可推进的时间
+type Clock interface {
+	Now() time.Time
+}
+
+// wallClock 使用系统时间的 Clock
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
 const (
 	//SDKName SDK名称，当前主要用于 log 中
 	SDKName = "qcloudsms-go-sdk"
@@ -126,6 +170,9 @@ const (
 	// PULLSENDSTATUS 发送数据统计
 	PULLSENDSTATUS string = "pullsendstatus"
 
+	// PULLMAX 拉取短信状态单次最大条数
+	PULLMAX int = 100
+
 	// PULLCBSTATUS 回执数据统计
 	PULLCBSTATUS string = "pullcallbackstatus"
 
@@ -140,6 +187,12 @@ const (
 	// MULTISMSMAX 群发短信单批次最大手机号数量
 	MULTISMSMAX int = 200
 
+	// BULKCONCURRENCY 批量群发短信默认的并发请求数
+	BULKCONCURRENCY int = 4
+
+	// REQUESTFAILED 请求没有得到接口的错误码时（如网络错误），批量群发结果中使用的错误码
+	REQUESTFAILED uint = ^uint(0)
+
 	// PROMPTVOICETYPE 语音类型，为2表示语音通知
 	PROMPTVOICETYPE uint = 2
 	//根据模板发送语音基本url
@@ -151,7 +204,7 @@ const (
 var (
 	//ErrMultiCount 群发号码数量错误
 	ErrMultiCount = errors.New("单次提交不超过200个手机号")
-	//ErrRequest 请求失败
+	//ErrRequest 请求失败，HTTP 状态码不为 200 的 *APIError 与之相同
 	ErrRequest = errors.New("请求失败")
 )
 
@@ -162,15 +215,19 @@ func NewOptions(appid, appkey, sign string) *Options {
 		APPKEY: appkey,
 		SIGN:   sign,
 
-		RandomLen: 6,
+		RandomLen: RANDOMLENMIN,
 		UserAgent: SDKName + "/" + SDKVersion,
 
-		Debug: false,
+		BulkConcurrency: BULKCONCURRENCY,
+
+		Clock: wallClock{},
 
-		HTTP: struct {
-			Timeout time.Duration
-		}{Timeout: 10 * time.Second},
+		BaseURL:      SVR,
+		VoiceBaseURL: VSVR,
+
+		Debug: false,
 	}
+	opt.HTTP.Timeout = 10 * time.Second
 
 	return opt
 }
@@ -180,8 +237,27 @@ func NewClient(o *Options) *QcloudSMS {
 	c := &QcloudSMS{}
 	c.Options = *o
 
-	c.NewRandom(c.Options.RandomLen)
-	c.ReqTime = time.Now().Unix()
+	if c.Options.Clock == nil {
+		c.Options.Clock = wallClock{}
+	}
+	if c.Options.Nonce == nil {
+		c.Options.Nonce = newCryptoNonce(NONCEWINDOW, c.Options.Clock)
+	}
+	if c.Options.RandomLen < RANDOMLENMIN {
+		c.Options.RandomLen = RANDOMLENMIN
+	}
+
+	c.transport = c.Options.HTTP.Transport
+	if c.transport == nil {
+		c.transport = newTransport()
+	}
+
+	c.signer = NewSigner(c.Options.APPKEY)
+
+	c.httpClient = c.Options.HTTP.Client
+	if c.httpClient == nil {
+		c.httpClient = c.newHTTPClient()
+	}
 
 	c.Logger = log.New(os.Stderr, "["+SDKName+"]", log.LstdFlags)
 	return c
@@ -196,6 +272,7 @@ func (c *QcloudSMS) SetAPPID(appid string) *QcloudSMS {
 // SetAPPKEY 为实例设置 APPKEY
 func (c *QcloudSMS) SetAPPKEY(appkey string) *QcloudSMS {
 	c.Options.APPKEY = appkey
+	c.signer = NewSigner(appkey)
 	return c
 }
 
@@ -220,79 +297,152 @@ func (c *QcloudSMS) SetDebug(debug bool) *QcloudSMS {
 	return c
 }
 
-// NewRandom 为实例生成新的随机数
-func (c *QcloudSMS) NewRandom(l int) *QcloudSMS {
-	str := "0123456789"
-	bytes := []byte(str)
-	result := []byte{}
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	for i := 0; i < l; i++ {
-		result = append(result, bytes[r.Intn(len(bytes))])
+// SetHTTPClient 为实例设置发送请求使用的 http.Client
+// client 为 nil 时恢复为默认的 http.Client，继续使用 NewClient 时创建的 Transport 和连接池
+func (c *QcloudSMS) SetHTTPClient(client *http.Client) *QcloudSMS {
+	c.Options.HTTP.Client = client
+	if client == nil {
+		client = c.newHTTPClient()
 	}
-	c.Random = string(result)
-
+	c.httpClient = client
 	return c
 }
 
-// NewSig 为实例生成 sig
-func (c *QcloudSMS) NewSig(m string) *QcloudSMS {
-	var t = strconv.FormatInt(c.ReqTime, 10)
-	var sigContent = "appkey=" + c.Options.APPKEY + "&random=" + c.Random + "&time=" + t
+// newHTTPClient 根据 Options.HTTP.Timeout 生成默认的 http.Client，使用 client 共享的 Transport
+func (c *QcloudSMS) newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   c.Options.HTTP.Timeout,
+		Transport: c.transport,
+	}
+}
 
-	if len(m) > 0 {
-		sigContent += "&mobile=" + m
+// newTransport 返回 client 默认使用的 http.Transport
+// 开启长连接和 HTTP/2，并放宽同一主机的空闲连接数，避免大量发送时重复握手
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
 	}
-	h := sha256.New()
-	h.Write([]byte(sigContent))
+}
 
-	c.Sig = fmt.Sprintf("%x", h.Sum(nil))
+// NewRequest 为一次 API 调用生成新的 Request，包含新的随机数和请求时间
+// 请求时间在每次调用时从 Options.Clock 读取
+func (c *QcloudSMS) NewRequest() *Request {
+	r := &Request{c: c}
+	r.NewRandom(c.Options.RandomLen)
+	r.ReqTime = c.Options.Clock.Now().Unix()
 
-	return c
+	return r
 }
 
-// NewURL 为实例设置 URL
-func (c *QcloudSMS) NewURL(api string) *QcloudSMS {
-	url := ""
-	if api == SENDVOICE || api == PROMPTVOICE {
-		url = VOICESVR
-	} else {
-		url = TLSSMSSVR
+// NewRandom 为请求生成新的随机数
+// 生成失败时，错误会在 Do 时返回
+func (r *Request) NewRandom(l int) *Request {
+	random, err := r.c.Options.Nonce.Nonce(l)
+	if err != nil {
+		r.err = err
+		return r
 	}
+	r.Random = random
 
-	c.URL = SVR + url + api + fmt.Sprintf(TLSSMSSVRAfter, c.Options.APPID, c.Random)
+	return r
+}
 
-	return c
+// NewSig 为请求生成 sig
+// m 为请求的号码，群发时为逗号连接的多个号码，不需要号码的接口为空
+func (r *Request) NewSig(m string) *Request {
+	r.Sig = r.c.signer.Sign(r.Random, r.ReqTime, m)
+
+	return r
 }
 
-// NewRequest 执行实例发送请求
-func (c *QcloudSMS) NewRequest(params interface{}) ([]byte, error) {
-	j, _ := json.Marshal(params)
+// NewURL 为请求设置 URL，地址由 Options.Endpoint 生成
+func (r *Request) NewURL(api string) *Request {
+	r.api = api
+	r.URL = r.c.Options.Endpoint(api) + fmt.Sprintf(TLSSMSSVRAfter, r.c.Options.APPID, r.Random)
 
-	req, err := http.NewRequest("POST", c.URL, bytes.NewBuffer([]byte(j)))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", c.Options.UserAgent)
+	return r
+}
+
+// Endpoint 返回接口 api 的地址，不包含 sdkappid 和 random 参数
+//
+// sendtvoice 使用 VoiceBaseURL，其他接口使用 BaseURL，
+// 路径优先使用 Paths 中的设置
+func (o *Options) Endpoint(api string) string {
+	base := o.BaseURL
+	if base == "" {
+		base = SVR
+	}
+	if api == TVOICE {
+		base = o.VoiceBaseURL
+		if base == "" {
+			base = VSVR
+		}
+	}
+
+	p, ok := o.Paths[api]
+	if !ok {
+		p = defaultPath(api)
+	}
+
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(p, "/")
+}
+
+// defaultPath 返回接口的默认路径，语音接口位于 VOICESVR 下，其他接口位于 TLSSMSSVR 下
+func defaultPath(api string) string {
+	switch api {
+	case SENDVOICE, PROMPTVOICE, TVOICE:
+		return VOICESVR + api
+	}
+
+	return TLSSMSSVR + api
+}
 
-	httpClient := &http.Client{
-		Timeout: c.Options.HTTP.Timeout,
+// Do 执行请求，请求的取消、超时等由 ctx 控制
+func (r *Request) Do(ctx context.Context, params interface{}) ([]byte, error) {
+	c := r.c
+	if r.err != nil {
+		return []byte{}, r.err
 	}
 
-	resp, err := httpClient.Do(req)
+	j, err := json.Marshal(params)
 	if err != nil {
 		return []byte{}, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return []byte{}, ErrRequest
+	req, err := http.NewRequestWithContext(ctx, "POST", r.URL, bytes.NewBuffer([]byte(j)))
+	if err != nil {
+		return []byte{}, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.Options.UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return []byte{}, err
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		return []byte{}, r.apiError(resp.StatusCode, body)
+	}
+
 	if c.Options.Debug {
-		c.Logger.Printf("Request Url : %s, Request Params : %s, Request Res : %s\n", c.URL, string(j), string(body))
+		c.Logger.Printf("Request Url : %s, Request Params : %s, Request Res : %s\n", r.URL, string(j), string(body))
 	}
 
 	return body, err
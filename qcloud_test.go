@@ -0,0 +1,107 @@
+package qcloudsms
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rewriteTransport 将请求发送到测试服务器
+type rewriteTransport struct {
+	url *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.url.Scheme
+	r.URL.Host = t.url.Host
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// newTestClient 返回请求全部发送到 h 的 client
+func newTestClient(t *testing.T, h http.Handler) *QcloudSMS {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := NewOptions("1400", "key", "sign")
+	opt.HTTP.Transport = rewriteTransport{u}
+
+	return NewClient(opt)
+}
+
+func TestRequestConcurrent(t *testing.T) {
+	c := NewClient(NewOptions("1400", "key", "sign"))
+
+	var wg sync.WaitGroup
+	reqs := make([]*Request, 64)
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reqs[i] = c.NewRequest().NewSig("13800000000").NewURL(SENDSMS)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, r := range reqs {
+		content := "appkey=key&random=" + r.Random + "&time=" + strconv.FormatInt(r.ReqTime, 10) + "&mobile=13800000000"
+		if want := fmt.Sprintf("%x", sha256.Sum256([]byte(content))); r.Sig != want {
+			t.Errorf("request %d: sig = %s, want %s", i, r.Sig, want)
+		}
+		if !strings.HasSuffix(r.URL, "random="+r.Random) {
+			t.Errorf("request %d: URL %s does not use random %s", i, r.URL, r.Random)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"result":0,"errmsg":"OK","sid":"1"}`))
+	}))
+
+	if _, err := c.SendSMSSingle(SMSSingleReq{Tel: SMSTel{Nationcode: "86", Mobile: "13800000000"}, Msg: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/v5/tlssmssvr/sendsms" {
+		t.Errorf("requests = %v, want /v5/tlssmssvr/sendsms", paths)
+	}
+}
+
+func TestSetHTTPClientNil(t *testing.T) {
+	opt := NewOptions("1400", "key", "sign")
+	opt.HTTP.Timeout = 3 * time.Second
+	c := NewClient(opt)
+	transport := c.httpClient.Transport
+
+	c.SetHTTPClient(&http.Client{})
+	c.SetHTTPClient(nil)
+	c.SetHTTPClient(nil)
+
+	if c.httpClient == nil {
+		t.Fatal("httpClient is nil after SetHTTPClient(nil)")
+	}
+	if c.httpClient.Timeout != 3*time.Second {
+		t.Errorf("Timeout = %v, want %v", c.httpClient.Timeout, 3*time.Second)
+	}
+	if c.httpClient.Transport != transport {
+		t.Error("SetHTTPClient(nil) replaced the client's Transport")
+	}
+	if c.Options.HTTP.Client != nil {
+		t.Errorf("Options.HTTP.Client = %v, want nil", c.Options.HTTP.Client)
+	}
+}
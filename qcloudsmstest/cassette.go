@@ -0,0 +1,269 @@
+package qcloudsmstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+)
+
+// redacted 录制时替换 URL 中 sdkappid 和 random 的值
+const redacted = "REDACTED"
+
+// volatileFields 每次请求都会变化的字段，录制时从请求体中去掉，回放时不参与匹配
+var volatileFields = []string{"sig", "time", "random"}
+
+// ErrNoInteraction 回放时没有找到与请求匹配的记录
+var ErrNoInteraction = errors.New("没有匹配的录制记录")
+
+// Interaction 录制的一次请求和响应
+type Interaction struct {
+	Method string `json:"method"`
+	// 请求地址，sdkappid 和 random 已替换为 REDACTED
+	URL string `json:"url"`
+	// 请求体，已去掉 sig、time 等每次请求都会变化的字段
+	Request json.RawMessage `json:"request,omitempty"`
+
+	StatusCode int    `json:"status_code"`
+	Response   string `json:"response"`
+}
+
+// Cassette 保存录制的请求和响应，用于在没有网络的环境中回放
+//
+// 录制时使用 Recorder 返回的 http.RoundTripper 请求真实接口，完成后调用 Save 保存；
+// 回放时通过 LoadCassette 读取，使用 Replayer 返回的 http.RoundTripper：
+//
+//	opt := qcloudsms.NewOptions(appid, appkey, sign)
+//	opt.HTTP.Transport = cassette.Replayer()
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	mu   sync.Mutex
+	used map[int]bool
+}
+
+// LoadCassette 读取 path 中保存的记录
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("录制文件 %s 解析失败: %v", path, err)
+	}
+
+	return c, nil
+}
+
+// Save 将记录保存到 path
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Recorder 返回录制请求的 http.RoundTripper，请求由 base 发出，base 为空时使用 http.DefaultTransport
+func (c *Cassette) Recorder(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &recorder{c: c, base: base}
+}
+
+// Replayer 返回回放记录的 http.RoundTripper，不会发出真实的请求
+//
+// 请求按方法、接口名称和去掉 sig、time 等字段后的请求体匹配，录制和回放时的基本 URL 可以不同，
+// 每条记录只回放一次，相同的请求按录制的顺序回放，没有匹配的记录时返回 ErrNoInteraction
+func (c *Cassette) Replayer() http.RoundTripper {
+	return &replayer{c: c}
+}
+
+// match 返回与请求匹配且未回放过的第一条记录
+func (c *Cassette) match(method, api string, body []byte) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.used == nil {
+		c.used = make(map[int]bool)
+	}
+
+	for i, in := range c.Interactions {
+		if c.used[i] || in.Method != method || requestAPI(in.URL) != api {
+			continue
+		}
+		if !bytes.Equal(recordedBody(in.Request), body) {
+			continue
+		}
+
+		c.used[i] = true
+		return in, true
+	}
+
+	return Interaction{}, false
+}
+
+func (c *Cassette) add(in Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, in)
+}
+
+type recorder struct {
+	c    *Cassette
+	base http.RoundTripper
+}
+
+func (t *recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, clone, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(clone)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	u := *r.URL
+	q := u.Query()
+	for _, key := range []string{"sdkappid", "random"} {
+		if q.Get(key) != "" {
+			q.Set(key, redacted)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	t.c.add(Interaction{
+		Method:     r.Method,
+		URL:        u.String(),
+		Request:    recordedBody(body),
+		StatusCode: resp.StatusCode,
+		Response:   string(data),
+	})
+
+	return resp, nil
+}
+
+type replayer struct {
+	c *Cassette
+}
+
+func (t *replayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	api := path.Base(r.URL.Path)
+	in, ok := t.c.match(r.Method, api, recordedBody(body))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, r.Method, api)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode:    in.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(in.Response))),
+		ContentLength: int64(len(in.Response)),
+		Request:       r,
+	}, nil
+}
+
+// readBody 读取请求体，返回请求体和可以继续发送的请求副本，不修改 r
+// 设置了 r.GetBody 时从 GetBody 读取，否则读取 r.Body，r.Body 总是会被关闭
+func readBody(r *http.Request) ([]byte, *http.Request, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, r, nil
+	}
+
+	rc := r.Body
+	if r.GetBody != nil {
+		r.Body.Close()
+		b, err := r.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		rc = b
+	}
+
+	body, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clone := r.Clone(r.Context())
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return body, clone, nil
+}
+
+// requestAPI 返回录制的 URL 对应的接口名称
+func requestAPI(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+
+	return path.Base(u.Path)
+}
+
+// recordedBody 返回保存到记录中的请求体，不是 JSON 时保存为字符串
+func recordedBody(body []byte) json.RawMessage {
+	body = normalizeBody(body)
+	if !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+
+	return body
+}
+
+// normalizeBody 去掉请求体中每次请求都会变化的字段，并按字段名排序
+// 不是 JSON 对象时原样返回
+func normalizeBody(body []byte) []byte {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	for _, key := range volatileFields {
+		delete(fields, key)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+
+	return data
+}
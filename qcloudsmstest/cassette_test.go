@@ -0,0 +1,108 @@
+package qcloudsmstest
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+)
+
+func TestCassetteRecordReplay(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+
+	cassette := &Cassette{}
+	opt := srv.NewOptions()
+	opt.HTTP.Transport = cassette.Recorder(nil)
+	client := qcloudsms.NewClient(opt)
+
+	req := qcloudsms.SMSSingleReq{Tel: tel, Msg: "test"}
+	first, err := client.SendSMSSingle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.SendSMSSingle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, failed := client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, TplID: 999})
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := cassette.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{"key", `"sig"`, `"time"`} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	loaded, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Interactions) != 3 {
+		t.Fatalf("loaded %d interactions, want 3", len(loaded.Interactions))
+	}
+	if u := loaded.Interactions[0].URL; !strings.Contains(u, "sdkappid="+redacted) || !strings.Contains(u, "random="+redacted) {
+		t.Errorf("URL = %s, want sdkappid and random redacted", u)
+	}
+
+	// 回放时使用不同的 appid、appkey 和基本 URL
+	replay := qcloudsms.NewOptions("1500", "other", "sign")
+	replay.HTTP.Transport = loaded.Replayer()
+	client = qcloudsms.NewClient(replay)
+
+	res, err := client.SendSMSSingle(req)
+	if err != nil || res.Sid != first.Sid {
+		t.Errorf("replay #1 = %+v, %v, want sid %s", res, err, first.Sid)
+	}
+	res, err = client.SendSMSSingle(req)
+	if err != nil || res.Sid != second.Sid {
+		t.Errorf("replay #2 = %+v, %v, want sid %s", res, err, second.Sid)
+	}
+	if _, err := client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, TplID: 999}); !errors.Is(err, qcloudsms.ErrTemplateMismatch) || failed == nil {
+		t.Errorf("replay error = %v, want ErrTemplateMismatch", err)
+	}
+	if _, err := client.SendSMSSingle(req); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("exhausted: err = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestCassetteDoesNotModifyRequest(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+
+	cassette := &Cassette{}
+	for _, tc := range []struct {
+		name string
+		rt   http.RoundTripper
+	}{
+		{"recorder", cassette.Recorder(srv.Transport())},
+		{"replayer", cassette.Replayer()},
+	} {
+		r, err := http.NewRequest(http.MethodPost, "https://yun.tim.qq.com/v5/tlssmssvr/pullstatus?sdkappid=1400&random=1", strings.NewReader(`{"max":10}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := r.Body
+		url := r.URL.String()
+
+		resp, err := tc.rt.RoundTrip(r)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+
+		if r.Body != body || r.URL.String() != url {
+			t.Errorf("%s modified the request", tc.name)
+		}
+	}
+}
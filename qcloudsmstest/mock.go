@@ -0,0 +1,377 @@
+package qcloudsmstest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+)
+
+// ErrNotMocked 调用了 Mock 中没有设置的方法
+var ErrNotMocked = errors.New("mock 方法未设置")
+
+// Call Mock 记录的一次调用
+type Call struct {
+	// 方法名称，不带 Context 后缀，如 SendSMSSingle
+	Method string
+	// 调用参数，多个参数时为 []interface{}
+	Args interface{}
+}
+
+// Mock 实现了 qcloudsms.Service，每个方法的行为由对应的函数字段决定
+//
+// 不带 Context 的方法使用 context.Background() 调用对应的函数，
+// 函数字段为空时返回零值和 ErrNotMocked，所有调用都会被记录，可以通过 Calls 取得：
+//
+//	m := &qcloudsmstest.Mock{
+//		SendSMSSingleFunc: func(ctx context.Context, ss qcloudsms.SMSSingleReq) (qcloudsms.SMSResult, error) {
+//			return qcloudsms.SMSResult{Sid: "sid"}, nil
+//		},
+//	}
+type Mock struct {
+	SendSMSSingleFunc      func(ctx context.Context, ss qcloudsms.SMSSingleReq) (qcloudsms.SMSResult, error)
+	SendSMSMultiFunc       func(ctx context.Context, sms qcloudsms.SMSMultiReq) (qcloudsms.SMSMultiResult, error)
+	SendSMSBulkFunc        func(ctx context.Context, sms qcloudsms.SMSMultiReq) (qcloudsms.SMSMultiResult, error)
+	PullStatusFunc         func(ctx context.Context, psr qcloudsms.PullStatusReq) (qcloudsms.PullStatusResult, error)
+	PullReplyFunc          func(ctx context.Context, psr qcloudsms.PullStatusReq) (qcloudsms.PullReplyResult, error)
+	GetStatusForMobileFunc func(ctx context.Context, smr qcloudsms.StatusMobileReq) (qcloudsms.StatusMobileResult, error)
+	GetReplyForMobileFunc  func(ctx context.Context, smr qcloudsms.StatusMobileReq) (qcloudsms.StatusReplyResult, error)
+	SendVoiceFunc          func(ctx context.Context, v qcloudsms.VoiceReq) (qcloudsms.VoiceResult, error)
+	VoiceTemplateSendFunc  func(ctx context.Context, s qcloudsms.SMSVoiceTemplate) (qcloudsms.VoiceResult, error)
+	GetTemplateByIDFunc    func(ctx context.Context, id []uint) (qcloudsms.TemplateGetResult, error)
+	GetTemplateByPageFunc  func(ctx context.Context, offset, max uint) (qcloudsms.TemplateGetResult, error)
+	NewTemplateFunc        func(ctx context.Context, t qcloudsms.TemplateNew) (qcloudsms.TemplateResult, error)
+	ModTemplateFunc        func(ctx context.Context, t qcloudsms.TemplateNew) (qcloudsms.TemplateResult, error)
+	DelTemplateFunc        func(ctx context.Context, id []uint) (qcloudsms.TemplateResult, error)
+	NewSignFunc            func(ctx context.Context, s qcloudsms.SignReq) (qcloudsms.SignResult, error)
+	ModSignFunc            func(ctx context.Context, s qcloudsms.SignReq) (qcloudsms.SignResult, error)
+	GetSignFunc            func(ctx context.Context, signid []uint) (qcloudsms.SignStatusResult, error)
+	DelSignFunc            func(ctx context.Context, signid []uint) (qcloudsms.SignResult, error)
+	GetStatusFunc          func(ctx context.Context, begin, end uint32) (qcloudsms.StatusResult, error)
+	GetSendStatusFunc      func(ctx context.Context, begin, end uint32) (qcloudsms.SendStatusResult, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ qcloudsms.Service = (*Mock)(nil)
+
+// Calls 返回记录的全部调用
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Call(nil), m.calls...)
+}
+
+func (m *Mock) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := Call{Method: method, Args: args}
+	if len(args) == 1 {
+		c.Args = args[0]
+	}
+	m.calls = append(m.calls, c)
+}
+
+// SendSMSSingle 调用 SendSMSSingleFunc
+func (m *Mock) SendSMSSingle(ss qcloudsms.SMSSingleReq) (qcloudsms.SMSResult, error) {
+	return m.SendSMSSingleContext(context.Background(), ss)
+}
+
+// SendSMSSingleContext 调用 SendSMSSingleFunc
+func (m *Mock) SendSMSSingleContext(ctx context.Context, ss qcloudsms.SMSSingleReq) (qcloudsms.SMSResult, error) {
+	m.record("SendSMSSingle", ss)
+	if m.SendSMSSingleFunc == nil {
+		return qcloudsms.SMSResult{}, ErrNotMocked
+	}
+
+	return m.SendSMSSingleFunc(ctx, ss)
+}
+
+// SendSMSMulti 调用 SendSMSMultiFunc
+func (m *Mock) SendSMSMulti(sms qcloudsms.SMSMultiReq) (qcloudsms.SMSMultiResult, error) {
+	return m.SendSMSMultiContext(context.Background(), sms)
+}
+
+// SendSMSMultiContext 调用 SendSMSMultiFunc
+func (m *Mock) SendSMSMultiContext(ctx context.Context, sms qcloudsms.SMSMultiReq) (qcloudsms.SMSMultiResult, error) {
+	m.record("SendSMSMulti", sms)
+	if m.SendSMSMultiFunc == nil {
+		return qcloudsms.SMSMultiResult{}, ErrNotMocked
+	}
+
+	return m.SendSMSMultiFunc(ctx, sms)
+}
+
+// SendSMSBulk 调用 SendSMSBulkFunc
+func (m *Mock) SendSMSBulk(sms qcloudsms.SMSMultiReq) (qcloudsms.SMSMultiResult, error) {
+	return m.SendSMSBulkContext(context.Background(), sms)
+}
+
+// SendSMSBulkContext 调用 SendSMSBulkFunc
+func (m *Mock) SendSMSBulkContext(ctx context.Context, sms qcloudsms.SMSMultiReq) (qcloudsms.SMSMultiResult, error) {
+	m.record("SendSMSBulk", sms)
+	if m.SendSMSBulkFunc == nil {
+		return qcloudsms.SMSMultiResult{}, ErrNotMocked
+	}
+
+	return m.SendSMSBulkFunc(ctx, sms)
+}
+
+// PullStatus 调用 PullStatusFunc
+func (m *Mock) PullStatus(psr qcloudsms.PullStatusReq) (qcloudsms.PullStatusResult, error) {
+	return m.PullStatusContext(context.Background(), psr)
+}
+
+// PullStatusContext 调用 PullStatusFunc
+func (m *Mock) PullStatusContext(ctx context.Context, psr qcloudsms.PullStatusReq) (qcloudsms.PullStatusResult, error) {
+	m.record("PullStatus", psr)
+	if m.PullStatusFunc == nil {
+		return qcloudsms.PullStatusResult{}, ErrNotMocked
+	}
+
+	return m.PullStatusFunc(ctx, psr)
+}
+
+// PullReply 调用 PullReplyFunc
+func (m *Mock) PullReply(psr qcloudsms.PullStatusReq) (qcloudsms.PullReplyResult, error) {
+	return m.PullReplyContext(context.Background(), psr)
+}
+
+// PullReplyContext 调用 PullReplyFunc
+func (m *Mock) PullReplyContext(ctx context.Context, psr qcloudsms.PullStatusReq) (qcloudsms.PullReplyResult, error) {
+	m.record("PullReply", psr)
+	if m.PullReplyFunc == nil {
+		return qcloudsms.PullReplyResult{}, ErrNotMocked
+	}
+
+	return m.PullReplyFunc(ctx, psr)
+}
+
+// GetStatusForMobile 调用 GetStatusForMobileFunc
+func (m *Mock) GetStatusForMobile(smr qcloudsms.StatusMobileReq) (qcloudsms.StatusMobileResult, error) {
+	return m.GetStatusForMobileContext(context.Background(), smr)
+}
+
+// GetStatusForMobileContext 调用 GetStatusForMobileFunc
+func (m *Mock) GetStatusForMobileContext(ctx context.Context, smr qcloudsms.StatusMobileReq) (qcloudsms.StatusMobileResult, error) {
+	m.record("GetStatusForMobile", smr)
+	if m.GetStatusForMobileFunc == nil {
+		return qcloudsms.StatusMobileResult{}, ErrNotMocked
+	}
+
+	return m.GetStatusForMobileFunc(ctx, smr)
+}
+
+// GetReplyForMobile 调用 GetReplyForMobileFunc
+func (m *Mock) GetReplyForMobile(smr qcloudsms.StatusMobileReq) (qcloudsms.StatusReplyResult, error) {
+	return m.GetReplyForMobileContext(context.Background(), smr)
+}
+
+// GetReplyForMobileContext 调用 GetReplyForMobileFunc
+func (m *Mock) GetReplyForMobileContext(ctx context.Context, smr qcloudsms.StatusMobileReq) (qcloudsms.StatusReplyResult, error) {
+	m.record("GetReplyForMobile", smr)
+	if m.GetReplyForMobileFunc == nil {
+		return qcloudsms.StatusReplyResult{}, ErrNotMocked
+	}
+
+	return m.GetReplyForMobileFunc(ctx, smr)
+}
+
+// SendVoice 调用 SendVoiceFunc
+func (m *Mock) SendVoice(v qcloudsms.VoiceReq) (qcloudsms.VoiceResult, error) {
+	return m.SendVoiceContext(context.Background(), v)
+}
+
+// SendVoiceContext 调用 SendVoiceFunc
+func (m *Mock) SendVoiceContext(ctx context.Context, v qcloudsms.VoiceReq) (qcloudsms.VoiceResult, error) {
+	m.record("SendVoice", v)
+	if m.SendVoiceFunc == nil {
+		return qcloudsms.VoiceResult{}, ErrNotMocked
+	}
+
+	return m.SendVoiceFunc(ctx, v)
+}
+
+// VoiceTemplateSend 调用 VoiceTemplateSendFunc
+func (m *Mock) VoiceTemplateSend(s qcloudsms.SMSVoiceTemplate) (qcloudsms.VoiceResult, error) {
+	return m.VoiceTemplateSendContext(context.Background(), s)
+}
+
+// VoiceTemplateSendContext 调用 VoiceTemplateSendFunc
+func (m *Mock) VoiceTemplateSendContext(ctx context.Context, s qcloudsms.SMSVoiceTemplate) (qcloudsms.VoiceResult, error) {
+	m.record("VoiceTemplateSend", s)
+	if m.VoiceTemplateSendFunc == nil {
+		return qcloudsms.VoiceResult{}, ErrNotMocked
+	}
+
+	return m.VoiceTemplateSendFunc(ctx, s)
+}
+
+// GetTemplateByID 调用 GetTemplateByIDFunc
+func (m *Mock) GetTemplateByID(id []uint) (qcloudsms.TemplateGetResult, error) {
+	return m.GetTemplateByIDContext(context.Background(), id)
+}
+
+// GetTemplateByIDContext 调用 GetTemplateByIDFunc
+func (m *Mock) GetTemplateByIDContext(ctx context.Context, id []uint) (qcloudsms.TemplateGetResult, error) {
+	m.record("GetTemplateByID", id)
+	if m.GetTemplateByIDFunc == nil {
+		return qcloudsms.TemplateGetResult{}, ErrNotMocked
+	}
+
+	return m.GetTemplateByIDFunc(ctx, id)
+}
+
+// GetTemplateByPage 调用 GetTemplateByPageFunc
+func (m *Mock) GetTemplateByPage(offset, max uint) (qcloudsms.TemplateGetResult, error) {
+	return m.GetTemplateByPageContext(context.Background(), offset, max)
+}
+
+// GetTemplateByPageContext 调用 GetTemplateByPageFunc
+func (m *Mock) GetTemplateByPageContext(ctx context.Context, offset, max uint) (qcloudsms.TemplateGetResult, error) {
+	m.record("GetTemplateByPage", offset, max)
+	if m.GetTemplateByPageFunc == nil {
+		return qcloudsms.TemplateGetResult{}, ErrNotMocked
+	}
+
+	return m.GetTemplateByPageFunc(ctx, offset, max)
+}
+
+// NewTemplate 调用 NewTemplateFunc
+func (m *Mock) NewTemplate(t qcloudsms.TemplateNew) (qcloudsms.TemplateResult, error) {
+	return m.NewTemplateContext(context.Background(), t)
+}
+
+// NewTemplateContext 调用 NewTemplateFunc
+func (m *Mock) NewTemplateContext(ctx context.Context, t qcloudsms.TemplateNew) (qcloudsms.TemplateResult, error) {
+	m.record("NewTemplate", t)
+	if m.NewTemplateFunc == nil {
+		return qcloudsms.TemplateResult{}, ErrNotMocked
+	}
+
+	return m.NewTemplateFunc(ctx, t)
+}
+
+// ModTemplate 调用 ModTemplateFunc
+func (m *Mock) ModTemplate(t qcloudsms.TemplateNew) (qcloudsms.TemplateResult, error) {
+	return m.ModTemplateContext(context.Background(), t)
+}
+
+// ModTemplateContext 调用 ModTemplateFunc
+func (m *Mock) ModTemplateContext(ctx context.Context, t qcloudsms.TemplateNew) (qcloudsms.TemplateResult, error) {
+	m.record("ModTemplate", t)
+	if m.ModTemplateFunc == nil {
+		return qcloudsms.TemplateResult{}, ErrNotMocked
+	}
+
+	return m.ModTemplateFunc(ctx, t)
+}
+
+// DelTemplate 调用 DelTemplateFunc
+func (m *Mock) DelTemplate(id []uint) (qcloudsms.TemplateResult, error) {
+	return m.DelTemplateContext(context.Background(), id)
+}
+
+// DelTemplateContext 调用 DelTemplateFunc
+func (m *Mock) DelTemplateContext(ctx context.Context, id []uint) (qcloudsms.TemplateResult, error) {
+	m.record("DelTemplate", id)
+	if m.DelTemplateFunc == nil {
+		return qcloudsms.TemplateResult{}, ErrNotMocked
+	}
+
+	return m.DelTemplateFunc(ctx, id)
+}
+
+// NewSign 调用 NewSignFunc
+func (m *Mock) NewSign(s qcloudsms.SignReq) (qcloudsms.SignResult, error) {
+	return m.NewSignContext(context.Background(), s)
+}
+
+// NewSignContext 调用 NewSignFunc
+func (m *Mock) NewSignContext(ctx context.Context, s qcloudsms.SignReq) (qcloudsms.SignResult, error) {
+	m.record("NewSign", s)
+	if m.NewSignFunc == nil {
+		return qcloudsms.SignResult{}, ErrNotMocked
+	}
+
+	return m.NewSignFunc(ctx, s)
+}
+
+// ModSign 调用 ModSignFunc
+func (m *Mock) ModSign(s qcloudsms.SignReq) (qcloudsms.SignResult, error) {
+	return m.ModSignContext(context.Background(), s)
+}
+
+// ModSignContext 调用 ModSignFunc
+func (m *Mock) ModSignContext(ctx context.Context, s qcloudsms.SignReq) (qcloudsms.SignResult, error) {
+	m.record("ModSign", s)
+	if m.ModSignFunc == nil {
+		return qcloudsms.SignResult{}, ErrNotMocked
+	}
+
+	return m.ModSignFunc(ctx, s)
+}
+
+// GetSign 调用 GetSignFunc
+func (m *Mock) GetSign(signid []uint) (qcloudsms.SignStatusResult, error) {
+	return m.GetSignContext(context.Background(), signid)
+}
+
+// GetSignContext 调用 GetSignFunc
+func (m *Mock) GetSignContext(ctx context.Context, signid []uint) (qcloudsms.SignStatusResult, error) {
+	m.record("GetSign", signid)
+	if m.GetSignFunc == nil {
+		return qcloudsms.SignStatusResult{}, ErrNotMocked
+	}
+
+	return m.GetSignFunc(ctx, signid)
+}
+
+// DelSign 调用 DelSignFunc
+func (m *Mock) DelSign(signid []uint) (qcloudsms.SignResult, error) {
+	return m.DelSignContext(context.Background(), signid)
+}
+
+// DelSignContext 调用 DelSignFunc
+func (m *Mock) DelSignContext(ctx context.Context, signid []uint) (qcloudsms.SignResult, error) {
+	m.record("DelSign", signid)
+	if m.DelSignFunc == nil {
+		return qcloudsms.SignResult{}, ErrNotMocked
+	}
+
+	return m.DelSignFunc(ctx, signid)
+}
+
+// GetStatus 调用 GetStatusFunc
+func (m *Mock) GetStatus(begin, end uint32) (qcloudsms.StatusResult, error) {
+	return m.GetStatusContext(context.Background(), begin, end)
+}
+
+// GetStatusContext 调用 GetStatusFunc
+func (m *Mock) GetStatusContext(ctx context.Context, begin, end uint32) (qcloudsms.StatusResult, error) {
+	m.record("GetStatus", begin, end)
+	if m.GetStatusFunc == nil {
+		return qcloudsms.StatusResult{}, ErrNotMocked
+	}
+
+	return m.GetStatusFunc(ctx, begin, end)
+}
+
+// GetSendStatus 调用 GetSendStatusFunc
+func (m *Mock) GetSendStatus(begin, end uint32) (qcloudsms.SendStatusResult, error) {
+	return m.GetSendStatusContext(context.Background(), begin, end)
+}
+
+// GetSendStatusContext 调用 GetSendStatusFunc
+func (m *Mock) GetSendStatusContext(ctx context.Context, begin, end uint32) (qcloudsms.SendStatusResult, error) {
+	m.record("GetSendStatus", begin, end)
+	if m.GetSendStatusFunc == nil {
+		return qcloudsms.SendStatusResult{}, ErrNotMocked
+	}
+
+	return m.GetSendStatusFunc(ctx, begin, end)
+}
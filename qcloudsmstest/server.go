@@ -0,0 +1,844 @@
+// Package qcloudsmstest 提供用于测试的腾讯云短信模拟服务器
+//
+// Server 基于 httptest 实现了 qcloudsms 中的全部接口，会校验 sdkappid、sig 和请求时间，
+// 在内存中保存模板和签名，并记录收到的请求，测试时无需访问 yun.tim.qq.com：
+//
+//	srv := qcloudsmstest.NewServer("appid", "appkey")
+//	defer srv.Close()
+//
+//	client := srv.NewClient()
+//	client.SendSMSSingle(req)
+//
+//	reqs := srv.Requests()
+package qcloudsmstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+)
+
+// Request 服务器收到的一次请求
+type Request struct {
+	// 请求的接口，如 sendsms
+	API string
+	// URL 中的 sdkappid
+	AppID string
+	// URL 中的 random
+	Random string
+	// 原始请求体
+	Body []byte
+}
+
+// Decode 将请求体解析到 v
+func (r Request) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// sign 内存中保存的签名
+type sign struct {
+	ID            uint   `json:"id"`
+	Text          string `json:"text"`
+	International uint   `json:"international,omitempty"`
+	Status        uint   `json:"status"`
+	Reply         string `json:"reply"`
+	ApplyTime     string `json:"apply_time"`
+}
+
+// Server 模拟腾讯云短信接口的测试服务器
+type Server struct {
+	AppID  string
+	AppKey string
+
+	// 校验 sig 和请求时间使用的 Signer，可以修改其 Clock 和 MaxAge
+	Signer *qcloudsms.Signer
+
+	// 与 qcloudsms.Options.Paths 相同，测试修改了接口路径的 client 时设置为相同的值
+	// 未设置的接口按路径的最后一段识别，如 /v5/tlssmssvr/sendsms 为 sendsms
+	Paths map[string]string
+
+	srv *httptest.Server
+
+	// intercept 在处理请求之前调用，返回 true 时表示已经写入响应，由 Simulator 设置
+	intercept func(w http.ResponseWriter, r *http.Request, api string) bool
+	// handled 请求处理成功后调用，由 Simulator 设置
+	handled func(api string, body []byte, res interface{})
+
+	mu        sync.Mutex
+	requests  []Request
+	templates map[uint]qcloudsms.Template
+	signs     map[uint]sign
+	nextID    uint
+	nextSid   uint64
+	statuses  []qcloudsms.SMSStatusResult
+	replies   []qcloudsms.SMSReplyResult
+	history   []qcloudsms.SMSStatusResult
+	replyLog  []qcloudsms.SMSReplyResult
+	requested uint
+	succeeded uint
+	billed    uint
+}
+
+// NewServer 启动一个模拟服务器，appid 和 appkey 用于校验请求
+func NewServer(appid, appkey string) *Server {
+	s := &Server{
+		AppID:     appid,
+		AppKey:    appkey,
+		Signer:    qcloudsms.NewSigner(appkey),
+		templates: make(map[uint]qcloudsms.Template),
+		signs:     make(map[uint]sign),
+		nextID:    1,
+	}
+	s.srv = httptest.NewServer(s)
+
+	return s
+}
+
+// URL 返回服务器地址
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Close 关闭服务器
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Transport 返回将所有请求转发到此服务器的 http.RoundTripper
+// 用于不修改 Options.BaseURL 的情况，如测试使用默认 Options 的代码
+func (s *Server) Transport() http.RoundTripper {
+	u, _ := url.Parse(s.srv.URL)
+	return &rewriteTransport{target: u, base: s.srv.Client().Transport}
+}
+
+// NewOptions 返回请求此服务器的 qcloudsms.Options
+// 短信和语音接口的基本 URL 均指向此服务器
+func (s *Server) NewOptions() *qcloudsms.Options {
+	opt := qcloudsms.NewOptions(s.AppID, s.AppKey, "sign")
+	opt.BaseURL = s.srv.URL
+	opt.VoiceBaseURL = s.srv.URL
+	opt.Paths = s.Paths
+
+	return opt
+}
+
+// NewClient 返回请求此服务器的 client
+func (s *Server) NewClient() *qcloudsms.QcloudSMS {
+	return qcloudsms.NewClient(s.NewOptions())
+}
+
+// Requests 返回收到的全部请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// AddTemplate 直接添加一个模板，返回模板 ID
+// t.ID 为 0 时自动分配
+func (s *Server) AddTemplate(t qcloudsms.Template) uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.ID == 0 {
+		t.ID = s.newID()
+	}
+	s.templates[t.ID] = t
+
+	return t.ID
+}
+
+// Templates 返回保存的全部模板，按 ID 排列
+func (s *Server) Templates() []qcloudsms.Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedTemplates()
+}
+
+// PushStatus 添加短信下发状态，之后可以通过 pullstatus 和 pullstatus4mobile 拉取
+func (s *Server) PushStatus(ss ...qcloudsms.SMSStatusResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statuses = append(s.statuses, ss...)
+	s.history = append(s.history, ss...)
+}
+
+// PushReply 添加短信回复，之后可以通过 pullstatus 和 pullstatus4mobile 拉取
+func (s *Server) PushReply(rs ...qcloudsms.SMSReplyResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replies = append(s.replies, rs...)
+	s.replyLog = append(s.replyLog, rs...)
+}
+
+// handlers 各接口的处理函数，返回响应内容
+var handlers = map[string]func(s *Server, body []byte) (interface{}, *qcloudsms.APIError){
+	qcloudsms.SENDSMS:        (*Server).sendSMS,
+	qcloudsms.MULTISMS:       (*Server).sendMulti,
+	qcloudsms.SENDVOICE:      (*Server).sendVoice,
+	qcloudsms.PROMPTVOICE:    (*Server).sendVoice,
+	qcloudsms.TVOICE:         (*Server).sendVoice,
+	qcloudsms.ADDTEMPLATE:    (*Server).addTemplate,
+	qcloudsms.MODTEMPLATE:    (*Server).modTemplate,
+	qcloudsms.GETTEMPLATE:    (*Server).getTemplate,
+	qcloudsms.DELTEMPLATE:    (*Server).delTemplate,
+	qcloudsms.ADDSIGN:        (*Server).addSign,
+	qcloudsms.MODSIGN:        (*Server).modSign,
+	qcloudsms.GETSIGN:        (*Server).getSign,
+	qcloudsms.DELSIGN:        (*Server).delSign,
+	qcloudsms.PULLSTATUS:     (*Server).pullStatus,
+	qcloudsms.MOBILESTATUS:   (*Server).pullMobile,
+	qcloudsms.PULLSENDSTATUS: (*Server).sendStatus,
+	qcloudsms.PULLCBSTATUS:   (*Server).callbackStatus,
+}
+
+// msgAPIs 使用 msg 而不是 errmsg 返回错误信息的接口
+var msgAPIs = map[string]bool{
+	qcloudsms.ADDTEMPLATE: true,
+	qcloudsms.MODTEMPLATE: true,
+	qcloudsms.GETTEMPLATE: true,
+	qcloudsms.DELTEMPLATE: true,
+	qcloudsms.ADDSIGN:     true,
+	qcloudsms.MODSIGN:     true,
+	qcloudsms.GETSIGN:     true,
+	qcloudsms.DELSIGN:     true,
+}
+
+// ServeHTTP 处理请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api := s.route(r.URL.Path)
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := Request{
+		API:    api,
+		AppID:  r.URL.Query().Get("sdkappid"),
+		Random: r.URL.Query().Get("random"),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	handler, ok := handlers[api]
+	if r.Method != http.MethodPost || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.intercept != nil && s.intercept(w, r, api) {
+		return
+	}
+
+	if e := s.verify(req); e != nil {
+		writeError(w, api, e)
+		return
+	}
+
+	res, e := handler(s, body)
+	if e != nil {
+		writeError(w, api, e)
+		return
+	}
+
+	if s.handled != nil {
+		s.handled(api, body, res)
+	}
+
+	writeJSON(w, res)
+}
+
+// route 返回请求路径对应的接口名称，优先匹配 Paths 中设置的路径
+func (s *Server) route(p string) string {
+	p = strings.Trim(p, "/")
+	for api, custom := range s.Paths {
+		custom = strings.Trim(custom, "/")
+		if p == custom || strings.HasSuffix(p, "/"+custom) {
+			return api
+		}
+	}
+
+	return path.Base(p)
+}
+
+// verify 校验 sdkappid、sig 和请求时间
+func (s *Server) verify(req Request) *qcloudsms.APIError {
+	if req.AppID != s.AppID {
+		return qcloudsms.ErrAppIDNotExist
+	}
+
+	var probe struct {
+		Sig  string          `json:"sig"`
+		Time int64           `json:"time"`
+		Tel  json.RawMessage `json:"tel"`
+	}
+	if err := json.Unmarshal(req.Body, &probe); err != nil {
+		return qcloudsms.ErrBadPackage
+	}
+	if probe.Sig == "" {
+		return qcloudsms.ErrSigEmpty
+	}
+
+	mobiles, err := sigMobiles(req.API, probe.Tel)
+	if err != nil {
+		return qcloudsms.ErrBadPackage
+	}
+
+	switch s.Signer.Verify(probe.Sig, req.Random, probe.Time, mobiles...) {
+	case nil, qcloudsms.ErrSigReplayed:
+		// 腾讯云不拒绝重复的请求，测试中使用固定的随机数和时间时 sig 也会相同
+		return nil
+	case qcloudsms.ErrSigExpired:
+		return qcloudsms.ErrTimeInvalid
+	default:
+		return qcloudsms.ErrSigVerify
+	}
+}
+
+// sigMobiles 返回计算 sig 时使用的号码
+func sigMobiles(api string, tel json.RawMessage) ([]string, error) {
+	switch api {
+	case qcloudsms.SENDSMS, qcloudsms.SENDVOICE, qcloudsms.PROMPTVOICE, qcloudsms.TVOICE:
+		var t qcloudsms.SMSTel
+		if err := json.Unmarshal(tel, &t); err != nil {
+			return nil, err
+		}
+		return []string{t.Mobile}, nil
+	case qcloudsms.MULTISMS:
+		var ts []qcloudsms.SMSTel
+		if err := json.Unmarshal(tel, &ts); err != nil {
+			return nil, err
+		}
+		mobiles := make([]string, 0, len(ts))
+		for _, t := range ts {
+			mobiles = append(mobiles, t.Mobile)
+		}
+		return mobiles, nil
+	}
+
+	return nil, nil
+}
+
+// writeError 写入错误响应
+func writeError(w http.ResponseWriter, api string, e *qcloudsms.APIError) {
+	res := map[string]interface{}{"result": e.Result}
+	if msgAPIs[api] {
+		res["msg"] = e.Errmsg
+	} else {
+		res["errmsg"] = e.Errmsg
+	}
+
+	writeJSON(w, res)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiError 生成带有自定义错误信息的错误
+func apiError(result uint, errmsg string) *qcloudsms.APIError {
+	return &qcloudsms.APIError{StatusCode: http.StatusOK, Result: result, Errmsg: errmsg}
+}
+
+// newID 分配模板或签名 ID，需要持有锁
+func (s *Server) newID() uint {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// newSid 分配发送标识，需要持有锁
+func (s *Server) newSid() string {
+	s.nextSid++
+	return fmt.Sprintf("%s:%d", s.AppID, s.nextSid)
+}
+
+// render 返回实际发送的短信内容，使用模板时替换模板参数，需要持有锁
+func (s *Server) render(tplID uint, msg string, params []string) (string, *qcloudsms.APIError) {
+	if tplID == 0 {
+		return msg, nil
+	}
+
+	t, ok := s.templates[tplID]
+	if !ok || t.Status != 0 {
+		return "", qcloudsms.ErrTemplateMismatch
+	}
+
+	text := t.Text
+	for i, p := range params {
+		text = strings.Replace(text, "{"+strconv.Itoa(i+1)+"}", p, -1)
+	}
+
+	return text, nil
+}
+
+// fee 返回短信的计费条数，70 字以内为 1 条，超过时按每条 67 字计算
+func fee(text string) uint {
+	n := len([]rune(text))
+	if n <= 70 {
+		return 1
+	}
+
+	return uint((n + 66) / 67)
+}
+
+func (s *Server) sendSMS(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.SMSSingleReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+	if req.Tel.Mobile == "" {
+		return nil, qcloudsms.ErrMobileFormat
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, e := s.render(uint(req.TplID), req.Msg, req.Params)
+	if e != nil {
+		return nil, e
+	}
+
+	res := qcloudsms.SMSResult{
+		Result: qcloudsms.SUCCESS,
+		Errmsg: "OK",
+		Ext:    req.Ext,
+		Sid:    s.newSid(),
+		Fee:    fee(text),
+	}
+	s.requested++
+	s.succeeded++
+	s.billed += res.Fee
+
+	return res, nil
+}
+
+func (s *Server) sendMulti(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.SMSMultiReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+	if len(req.Tel) > qcloudsms.MULTISMSMAX {
+		return nil, qcloudsms.ErrMultiTooMany
+	}
+
+	domestic := 0
+	for _, t := range req.Tel {
+		if strings.TrimPrefix(t.Nationcode, "+") == "86" {
+			domestic++
+		}
+	}
+	if domestic != 0 && domestic != len(req.Tel) {
+		return nil, qcloudsms.ErrMixedNationcode
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, e := s.render(req.TplID, req.Msg, req.Params)
+	if e != nil {
+		return nil, e
+	}
+
+	res := qcloudsms.SMSMultiResult{
+		Result: qcloudsms.SUCCESS,
+		Errmsg: "OK",
+		Ext:    req.Ext,
+	}
+	for _, t := range req.Tel {
+		d := qcloudsms.SMSMultiDetail{
+			Mobile:     t.Mobile,
+			Nationcode: t.Nationcode,
+		}
+		s.requested++
+
+		if t.Mobile == "" {
+			d.Result = qcloudsms.ErrMobileFormat.Result
+			d.Errmsg = qcloudsms.ErrMobileFormat.Errmsg
+		} else {
+			d.Errmsg = "OK"
+			d.Sid = s.newSid()
+			d.Fee = fee(text)
+			s.succeeded++
+			s.billed += d.Fee
+		}
+		res.Detail = append(res.Detail, d)
+	}
+
+	return res, nil
+}
+
+func (s *Server) sendVoice(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req struct {
+		Tel qcloudsms.SMSTel `json:"tel"`
+		Ext string           `json:"ext"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+	if req.Tel.Mobile == "" {
+		return nil, qcloudsms.ErrMobileFormat
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return qcloudsms.VoiceResult{
+		Result: qcloudsms.SUCCESS,
+		Errmsg: "OK",
+		Ext:    req.Ext,
+		Callid: s.newSid(),
+	}, nil
+}
+
+func (s *Server) addTemplate(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.TemplateNew
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := qcloudsms.Template{
+		ID:            s.newID(),
+		Text:          req.Text,
+		Type:          req.Type,
+		International: req.International,
+		ApplyTime:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+	s.templates[t.ID] = t
+
+	return qcloudsms.TemplateResult{Result: qcloudsms.SUCCESS, Data: t}, nil
+}
+
+func (s *Server) modTemplate(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.TemplateNew
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.templates[req.TplID]
+	if !ok {
+		return nil, apiError(qcloudsms.ErrTemplateMismatch.Result, "模板不存在")
+	}
+	t.Text = req.Text
+	t.Type = req.Type
+	t.International = req.International
+	s.templates[t.ID] = t
+
+	return qcloudsms.TemplateResult{Result: qcloudsms.SUCCESS, Data: t}, nil
+}
+
+func (s *Server) getTemplate(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.TemplateGetReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.sortedTemplates()
+	res := qcloudsms.TemplateGetResult{Result: qcloudsms.SUCCESS, Total: uint(len(all))}
+
+	if len(req.TplID) > 0 {
+		for _, id := range req.TplID {
+			if t, ok := s.templates[id]; ok {
+				res.Data = append(res.Data, t)
+			}
+		}
+	} else {
+		offset, max := int(req.TplPage.Offset), int(req.TplPage.Max)
+		if offset > len(all) {
+			offset = len(all)
+		}
+		end := offset + max
+		if max == 0 || end > len(all) {
+			end = len(all)
+		}
+		res.Data = all[offset:end]
+	}
+	res.Count = uint(len(res.Data))
+
+	return res, nil
+}
+
+func (s *Server) delTemplate(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.TemplateDelReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range req.TplID {
+		delete(s.templates, id)
+	}
+
+	return qcloudsms.TemplateResult{Result: qcloudsms.SUCCESS}, nil
+}
+
+// sortedTemplates 返回按 ID 排列的模板，需要持有锁
+func (s *Server) sortedTemplates() []qcloudsms.Template {
+	ts := make([]qcloudsms.Template, 0, len(s.templates))
+	for _, t := range s.templates {
+		ts = append(ts, t)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].ID < ts[j].ID })
+
+	return ts
+}
+
+func (s *Server) addSign(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.SignReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sg := sign{
+		ID:            s.newID(),
+		Text:          req.Text,
+		International: uint(req.International),
+		ApplyTime:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+	s.signs[sg.ID] = sg
+
+	return signResult(sg), nil
+}
+
+func (s *Server) modSign(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.SignReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sg, ok := s.signs[req.SignID]
+	if !ok {
+		return nil, apiError(qcloudsms.ErrSignInvalid.Result, "签名不存在")
+	}
+	sg.Text = req.Text
+	sg.International = uint(req.International)
+	s.signs[sg.ID] = sg
+
+	return signResult(sg), nil
+}
+
+func (s *Server) getSign(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.SignDelGet
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := []sign{}
+	for _, id := range req.SignID {
+		if sg, ok := s.signs[id]; ok {
+			data = append(data, sg)
+		}
+	}
+
+	return map[string]interface{}{
+		"result": qcloudsms.SUCCESS,
+		"msg":    "",
+		"count":  len(data),
+		"data":   data,
+	}, nil
+}
+
+func (s *Server) delSign(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req qcloudsms.SignDelGet
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range req.SignID {
+		delete(s.signs, id)
+	}
+
+	return qcloudsms.SignResult{Result: qcloudsms.SUCCESS}, nil
+}
+
+// signResult 返回添加、修改签名的响应
+func signResult(sg sign) qcloudsms.SignResult {
+	res := qcloudsms.SignResult{Result: qcloudsms.SUCCESS}
+	res.Data.ID = sg.ID
+	res.Data.International = sg.International
+	res.Data.Text = sg.Text
+	res.Data.Status = sg.Status
+
+	return res
+}
+
+// pullReq 拉取短信状态的请求结构
+type pullReq struct {
+	Type       int    `json:"type"`
+	Max        int    `json:"max"`
+	BeginTime  int64  `json:"begin_time"`
+	EndTime    int64  `json:"end_time"`
+	Nationcode string `json:"nationcode"`
+	Mobile     string `json:"mobile"`
+}
+
+func (r pullReq) limit() int {
+	if r.Max <= 0 || r.Max > qcloudsms.PULLMAX {
+		return qcloudsms.PULLMAX
+	}
+
+	return r.Max
+}
+
+func (s *Server) pullStatus(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req pullReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := req.limit()
+	if req.Type == 1 {
+		if n > len(s.replies) {
+			n = len(s.replies)
+		}
+		data := append([]qcloudsms.SMSReplyResult{}, s.replies[:n]...)
+		s.replies = s.replies[n:]
+
+		return qcloudsms.PullReplyResult{Count: len(data), Data: data}, nil
+	}
+
+	if n > len(s.statuses) {
+		n = len(s.statuses)
+	}
+	data := append([]qcloudsms.SMSStatusResult{}, s.statuses[:n]...)
+	s.statuses = s.statuses[n:]
+
+	return qcloudsms.PullStatusResult{Count: len(data), Data: data}, nil
+}
+
+func (s *Server) pullMobile(body []byte) (interface{}, *qcloudsms.APIError) {
+	var req pullReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, qcloudsms.ErrBadPackage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := req.limit()
+	if req.Type == 1 {
+		data := []qcloudsms.SMSReplyResult{}
+		for _, r := range s.replyLog {
+			if len(data) < n && r.Mobile == req.Mobile && r.Time >= req.BeginTime && r.Time <= req.EndTime {
+				data = append(data, r)
+			}
+		}
+
+		return qcloudsms.StatusReplyResult{Count: len(data), Data: data}, nil
+	}
+
+	data := []qcloudsms.SMSStatusResult{}
+	for _, st := range s.history {
+		t := st.UserReceiveTime.Unix()
+		if len(data) < n && st.Mobile == req.Mobile && t >= req.BeginTime && t <= req.EndTime {
+			data = append(data, st)
+		}
+	}
+
+	return qcloudsms.StatusMobileResult{Count: len(data), Data: data}, nil
+}
+
+func (s *Server) sendStatus(body []byte) (interface{}, *qcloudsms.APIError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := qcloudsms.SendStatusResult{Result: qcloudsms.SUCCESS}
+	res.Data.Request = s.requested
+	res.Data.Success = s.succeeded
+	res.Data.BillNumber = s.billed
+
+	return res, nil
+}
+
+func (s *Server) callbackStatus(body []byte) (interface{}, *qcloudsms.APIError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := qcloudsms.StatusResult{Result: qcloudsms.SUCCESS}
+	res.Data.Success = s.succeeded
+	for _, st := range s.history {
+		res.Data.Status++
+		if st.Success() {
+			res.Data.StatusSuccess++
+			continue
+		}
+
+		res.Data.StatusFail++
+		switch st.Category() {
+		case qcloudsms.CategoryInvalidNumber:
+			res.Data.StatusFail1++
+		case qcloudsms.CategoryPoweredOff:
+			res.Data.StatusFail2++
+		case qcloudsms.CategoryBlacklist:
+			res.Data.StatusFail3++
+		case qcloudsms.CategoryRateLimit:
+			res.Data.StatusFail4++
+		default:
+			res.Data.StatusFail0++
+		}
+	}
+
+	return res, nil
+}
+
+// rewriteTransport 将请求转发到 target
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = t.target.Host
+
+	return t.base.RoundTrip(r)
+}
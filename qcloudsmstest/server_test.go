@@ -0,0 +1,420 @@
+package qcloudsmstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+)
+
+type fixedClock struct{ t time.Time }
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+var tel = qcloudsms.SMSTel{Nationcode: "86", Mobile: "13800000000"}
+
+func TestSendSMS(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	id := srv.AddTemplate(qcloudsms.Template{Text: "验证码 {1}"})
+	res, err := client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, TplID: int(id), Params: []string{"1234"}, Ext: "ext"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sid == "" || res.Fee != 1 || res.Ext != "ext" {
+		t.Errorf("SendSMSSingle = %+v", res)
+	}
+
+	res, err = client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, Msg: strings.Repeat("字", 71)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Fee != 2 {
+		t.Errorf("Fee = %d, want 2", res.Fee)
+	}
+
+	_, err = client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, TplID: 999})
+	if !errors.Is(err, qcloudsms.ErrTemplateMismatch) {
+		t.Errorf("unknown template: err = %v, want ErrTemplateMismatch", err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 3 || reqs[0].API != qcloudsms.SENDSMS {
+		t.Fatalf("Requests() = %+v", reqs)
+	}
+	var req qcloudsms.SMSSingleReq
+	if err := reqs[0].Decode(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Tel != tel || req.Params[0] != "1234" {
+		t.Errorf("recorded request = %+v", req)
+	}
+}
+
+func TestSendSMSMulti(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	res, err := client.SendSMSMulti(qcloudsms.SMSMultiReq{
+		Tel: []qcloudsms.SMSTel{tel, {Nationcode: "86", Mobile: "13800000001"}},
+		Msg: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Succeeded()) != 2 || len(res.Failed()) != 0 {
+		t.Errorf("SendSMSMulti = %+v", res)
+	}
+
+	_, err = client.SendSMSMulti(qcloudsms.SMSMultiReq{
+		Tel: []qcloudsms.SMSTel{tel, {Nationcode: "1", Mobile: "2025550100"}},
+		Msg: "test",
+	})
+	if !errors.Is(err, qcloudsms.ErrMixedNationcode) {
+		t.Errorf("mixed nationcode: err = %v, want ErrMixedNationcode", err)
+	}
+}
+
+func TestSendVoice(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	var v qcloudsms.VoiceReq
+	v.Tel.Nationcode, v.Tel.Mobile = tel.Nationcode, tel.Mobile
+	v.Msg = "1234"
+	if res, err := client.SendVoice(v); err != nil || res.Callid == "" {
+		t.Errorf("SendVoice = %+v, %v", res, err)
+	}
+
+	v.Prompttype = qcloudsms.PROMPTVOICETYPE
+	v.Promptfile = "通知"
+	if res, err := client.SendVoice(v); err != nil || res.Callid == "" {
+		t.Errorf("SendVoice prompt = %+v, %v", res, err)
+	}
+
+	var tv qcloudsms.SMSVoiceTemplate
+	tv.Tel.Nationcode, tv.Tel.Mobile = tel.Nationcode, tel.Mobile
+	if res, err := client.VoiceTemplateSend(tv); err != nil || res.Callid == "" {
+		t.Errorf("VoiceTemplateSend = %+v, %v", res, err)
+	}
+
+	var apis []string
+	for _, r := range srv.Requests() {
+		apis = append(apis, r.API)
+	}
+	want := []string{qcloudsms.SENDVOICE, qcloudsms.PROMPTVOICE, qcloudsms.TVOICE}
+	if strings.Join(apis, ",") != strings.Join(want, ",") {
+		t.Errorf("APIs = %v, want %v", apis, want)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	res, err := client.NewTemplate(qcloudsms.TemplateNew{Title: "t", Text: "你好 {1}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := res.Data.ID
+
+	if _, err := client.ModTemplate(qcloudsms.TemplateNew{TplID: id, Text: "您好 {1}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.GetTemplateByID([]uint{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Count != 1 || got.Data[0].Text != "您好 {1}" {
+		t.Errorf("GetTemplateByID = %+v", got)
+	}
+
+	if _, err := client.NewTemplate(qcloudsms.TemplateNew{Text: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := client.GetTemplateByPage(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.Count != 1 || page.Data[0].Text != "second" {
+		t.Errorf("GetTemplateByPage = %+v", page)
+	}
+
+	if _, err := client.DelTemplate([]uint{id}); err != nil {
+		t.Fatal(err)
+	}
+	if ts := srv.Templates(); len(ts) != 1 {
+		t.Errorf("Templates() = %+v, want 1 template", ts)
+	}
+
+	_, err = client.ModTemplate(qcloudsms.TemplateNew{TplID: id, Text: "x"})
+	if !errors.Is(err, qcloudsms.ErrTemplateMismatch) {
+		t.Errorf("ModTemplate deleted: err = %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	res, err := client.NewSign(qcloudsms.SignReq{Text: "签名"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := res.Data.ID
+
+	if _, err := client.ModSign(qcloudsms.SignReq{SignID: id, Text: "新签名"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.GetSign([]uint{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Data) != 1 || got.Data[0].Text != "新签名" {
+		t.Errorf("GetSign = %+v", got)
+	}
+
+	if _, err := client.DelSign([]uint{id}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = client.GetSign([]uint{id})
+	if err != nil || len(got.Data) != 0 {
+		t.Errorf("GetSign deleted = %+v, %v", got, err)
+	}
+}
+
+func TestPull(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	now := time.Now()
+	srv.PushStatus(
+		qcloudsms.SMSStatusResult{Mobile: tel.Mobile, Sid: "a", ReportStatus: qcloudsms.ReportSuccess, UserReceiveTime: qcloudsms.ReportTime{Time: now}},
+		qcloudsms.SMSStatusResult{Mobile: tel.Mobile, Sid: "b", ReportStatus: qcloudsms.ReportFail, Errmsg: "MK:0005", UserReceiveTime: qcloudsms.ReportTime{Time: now}},
+		qcloudsms.SMSStatusResult{Mobile: "13800000001", Sid: "c", ReportStatus: qcloudsms.ReportSuccess, UserReceiveTime: qcloudsms.ReportTime{Time: now}},
+	)
+	srv.PushReply(qcloudsms.SMSReplyResult{Mobile: tel.Mobile, Text: "TD", Time: now.Unix()})
+
+	st, err := client.PullStatus(qcloudsms.PullStatusReq{Max: 2})
+	if err != nil || st.Count != 2 || st.Data[0].Sid != "a" {
+		t.Fatalf("PullStatus = %+v, %v", st, err)
+	}
+	st, err = client.PullStatus(qcloudsms.PullStatusReq{Max: 10})
+	if err != nil || st.Count != 1 || st.Data[0].Sid != "c" {
+		t.Fatalf("PullStatus again = %+v, %v", st, err)
+	}
+
+	rp, err := client.PullReply(qcloudsms.PullStatusReq{Max: 10})
+	if err != nil || rp.Count != 1 || rp.Data[0].Text != "TD" {
+		t.Fatalf("PullReply = %+v, %v", rp, err)
+	}
+
+	q := qcloudsms.StatusMobileReq{
+		Max:        10,
+		BeginTime:  now.Add(-time.Minute).Unix(),
+		EndTime:    now.Add(time.Minute).Unix(),
+		Nationcode: tel.Nationcode,
+		Mobile:     tel.Mobile,
+	}
+	sm, err := client.GetStatusForMobile(q)
+	if err != nil || sm.Count != 2 {
+		t.Errorf("GetStatusForMobile = %+v, %v", sm, err)
+	}
+	rm, err := client.GetReplyForMobile(q)
+	if err != nil || rm.Count != 1 {
+		t.Errorf("GetReplyForMobile = %+v, %v", rm, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, Msg: "test"})
+	srv.PushStatus(
+		qcloudsms.SMSStatusResult{Mobile: tel.Mobile, ReportStatus: qcloudsms.ReportSuccess},
+		qcloudsms.SMSStatusResult{Mobile: tel.Mobile, ReportStatus: qcloudsms.ReportFail, Errmsg: "MK:0005"},
+	)
+
+	send, err := client.GetSendStatus(0, 1)
+	if err != nil || send.Data.Request != 1 || send.Data.Success != 1 || send.Data.BillNumber != 1 {
+		t.Errorf("GetSendStatus = %+v, %v", send, err)
+	}
+
+	cb, err := client.GetStatus(0, 1)
+	if err != nil || cb.Data.StatusSuccess != 1 || cb.Data.StatusFail != 1 || cb.Data.StatusFail2 != 1 {
+		t.Errorf("GetStatus = %+v, %v", cb, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	req := qcloudsms.SMSSingleReq{Tel: tel, Msg: "test"}
+
+	client := srv.NewClient()
+	client.SetAPPKEY("wrong")
+	if _, err := client.SendSMSSingle(req); !errors.Is(err, qcloudsms.ErrSigVerify) {
+		t.Errorf("wrong appkey: err = %v, want ErrSigVerify", err)
+	}
+
+	client = srv.NewClient()
+	client.SetAPPID("1401")
+	if _, err := client.SendSMSSingle(req); !errors.Is(err, qcloudsms.ErrAppIDNotExist) {
+		t.Errorf("wrong sdkappid: err = %v, want ErrAppIDNotExist", err)
+	}
+
+	opt := srv.NewOptions()
+	opt.Clock = fixedClock{time.Now().Add(-time.Hour)}
+	client = qcloudsms.NewClient(opt)
+	if _, err := client.SendSMSSingle(req); !errors.Is(err, qcloudsms.ErrTimeInvalid) {
+		t.Errorf("stale time: err = %v, want ErrTimeInvalid", err)
+	}
+
+	// 号码参与签名，修改号码后 sig 不再匹配
+	opt = srv.NewOptions()
+	opt.HTTP.Transport = rewriteBody{base: srv.Transport(), from: tel.Mobile, to: "13900000000"}
+	client = qcloudsms.NewClient(opt)
+	if _, err := client.SendSMSSingle(req); !errors.Is(err, qcloudsms.ErrSigVerify) {
+		t.Errorf("tampered mobile: err = %v, want ErrSigVerify", err)
+	}
+}
+
+// rewriteBody 替换请求体中的内容，模拟被篡改的请求
+type rewriteBody struct {
+	base     http.RoundTripper
+	from, to string
+}
+
+func (t rewriteBody) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.Replace(body, []byte(t.from), []byte(t.to), -1)
+
+	r = r.Clone(r.Context())
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	return t.base.RoundTrip(r)
+}
+
+// bulkTel 返回 450 个号码，国内和国际号码交替出现
+func bulkTel() []qcloudsms.SMSTel {
+	var tel []qcloudsms.SMSTel
+	for i := 0; i < 450; i++ {
+		if i%3 == 0 {
+			tel = append(tel, qcloudsms.SMSTel{Nationcode: "1", Mobile: fmt.Sprintf("20255%05d", i)})
+		} else {
+			tel = append(tel, qcloudsms.SMSTel{Nationcode: "86", Mobile: fmt.Sprintf("138%08d", i)})
+		}
+	}
+
+	return tel
+}
+
+func TestSendSMSBulk(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	tel := bulkTel()
+	res, err := client.SendSMSBulk(qcloudsms.SMSMultiReq{Tel: tel, Msg: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 国内 300 个分为 2 批，国际 150 个分为 1 批
+	reqs := srv.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("server got %d requests, want 3", len(reqs))
+	}
+	for i, r := range reqs {
+		var req qcloudsms.SMSMultiReq
+		if err := r.Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range req.Tel {
+			if (n.Nationcode == "86") != (req.Tel[0].Nationcode == "86") {
+				t.Errorf("request %d mixes domestic and international numbers", i)
+				break
+			}
+		}
+	}
+
+	if len(res.Detail) != len(tel) || len(res.Succeeded()) != len(tel) {
+		t.Fatalf("got %d details, %d succeeded", len(res.Detail), len(res.Succeeded()))
+	}
+	for i, d := range res.Detail {
+		if d.Tel() != tel[i] {
+			t.Fatalf("Detail[%d] = %+v, want %+v", i, d, tel[i])
+		}
+	}
+}
+
+func TestSendSMSBulkCanceled(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	client := srv.NewClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tel := bulkTel()
+	res, err := client.SendSMSBulkContext(ctx, qcloudsms.SMSMultiReq{Tel: tel, Msg: "test"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if reqs := srv.Requests(); len(reqs) != 0 {
+		t.Errorf("server got %d requests after cancel", len(reqs))
+	}
+	if len(res.Detail) != len(tel) || len(res.Failed()) != len(tel) {
+		t.Errorf("got %d details, %d failed", len(res.Detail), len(res.Failed()))
+	}
+}
+
+func TestPaths(t *testing.T) {
+	srv := NewServer("1400", "key")
+	defer srv.Close()
+	srv.Paths = map[string]string{
+		qcloudsms.SENDSMS:  "sms/send",
+		qcloudsms.MULTISMS: "/sms/multi/",
+	}
+	client := srv.NewClient()
+
+	if _, err := client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, Msg: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendSMSMulti(qcloudsms.SMSMultiReq{Tel: []qcloudsms.SMSTel{tel}, Msg: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PullStatus(qcloudsms.PullStatusReq{Max: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := srv.Requests()
+	want := []string{qcloudsms.SENDSMS, qcloudsms.MULTISMS, qcloudsms.PULLSTATUS}
+	for i, r := range reqs {
+		if r.API != want[i] {
+			t.Errorf("request %d API = %s, want %s", i, r.API, want[i])
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package qcloudsmstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+)
+
+// AnyAPI 在 SetLatency 和 SetFaults 中表示所有接口
+const AnyAPI = "*"
+
+// Latency 响应延迟的分布
+type Latency interface {
+	Delay(r *rand.Rand) time.Duration
+}
+
+// LatencyFunc 将普通函数转换为 Latency
+type LatencyFunc func(r *rand.Rand) time.Duration
+
+// Delay 调用 f(r)
+func (f LatencyFunc) Delay(r *rand.Rand) time.Duration {
+	return f(r)
+}
+
+// FixedLatency 返回固定为 d 的延迟
+func FixedLatency(d time.Duration) Latency {
+	return LatencyFunc(func(*rand.Rand) time.Duration {
+		return d
+	})
+}
+
+// UniformLatency 返回在 [min, max) 内均匀分布的延迟
+func UniformLatency(min, max time.Duration) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	})
+}
+
+// NormalLatency 返回平均值为 mean、标准差为 stddev 的正态分布延迟，小于 0 时为 0
+func NormalLatency(mean, stddev time.Duration) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		d := mean + time.Duration(r.NormFloat64()*float64(stddev))
+		if d < 0 {
+			return 0
+		}
+		return d
+	})
+}
+
+// ExponentialLatency 返回平均值为 mean 的指数分布延迟，可用于模拟长尾请求
+func ExponentialLatency(mean time.Duration) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	})
+}
+
+// Fault 注入的故障
+type Fault struct {
+	// 触发的概率，0 到 1
+	Rate float64
+	// 返回的错误码，如 qcloudsms.ErrFrequencyLimit，为空时返回 qcloudsms.ErrServerTimeout
+	Err *qcloudsms.APIError
+	// 不为 0 时返回该 HTTP 状态码，如 502
+	StatusCode int
+	// 为 true 时返回无法解析的 JSON
+	Malformed bool
+}
+
+// ResultFault 以 rate 的概率返回错误码 err
+func ResultFault(rate float64, err *qcloudsms.APIError) Fault {
+	return Fault{Rate: rate, Err: err}
+}
+
+// HTTPFault 以 rate 的概率返回 HTTP 状态码 code
+func HTTPFault(rate float64, code int) Fault {
+	return Fault{Rate: rate, StatusCode: code}
+}
+
+// MalformedFault 以 rate 的概率返回无法解析的 JSON
+func MalformedFault(rate float64) Fault {
+	return Fault{Rate: rate, Malformed: true}
+}
+
+// write 写入故障对应的响应
+func (f Fault) write(w http.ResponseWriter, api string) {
+	switch {
+	case f.StatusCode != 0:
+		http.Error(w, http.StatusText(f.StatusCode), f.StatusCode)
+	case f.Malformed:
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"result":0,"errmsg":"OK","sid":`)
+	case f.Err != nil:
+		writeError(w, api, f.Err)
+	default:
+		writeError(w, api, qcloudsms.ErrServerTimeout)
+	}
+}
+
+// Simulator 在 Server 的基础上模拟慢速、不稳定的短信平台
+//
+// 可以为各接口设置响应延迟和故障，发送成功的短信会在随机延迟后生成下发状态和回复，
+// 可以通过拉取接口取得，设置了 CallbackURL 时同时推送到该地址。
+// 下发状态和回复的设置需在发送请求之前完成，延迟和故障可以随时修改
+type Simulator struct {
+	*Server
+
+	// 生成下发状态的比例，0 到 1，为 0 时不生成
+	ReportRate float64
+	// 下发状态的延迟，为空时立即生成
+	ReportDelay Latency
+	// 下发失败的比例，0 到 1
+	ReportFailRate float64
+	// 下发失败时随机使用的运营商错误码，默认为 MK:0005
+	ReportFailCodes []string
+
+	// 生成回复的比例，0 到 1，为 0 时不生成
+	ReplyRate float64
+	// 回复的延迟，为空时立即生成
+	ReplyDelay Latency
+	// 回复内容，默认为 "收到"
+	ReplyText string
+
+	// 下发状态和回复的推送地址，为空时不推送
+	CallbackURL string
+	// 推送使用的 http.Client，默认为 http.DefaultClient
+	HTTPClient *http.Client
+	// 推送失败时调用，为空时忽略推送错误
+	OnError func(err error)
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	latency map[string]Latency
+	faults  map[string][]Fault
+	timers  map[*time.Timer]bool
+	closed  bool
+}
+
+// NewSimulator 启动一个模拟服务器，appid 和 appkey 用于校验请求
+func NewSimulator(appid, appkey string) *Simulator {
+	sim := &Simulator{
+		Server:  NewServer(appid, appkey),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		latency: make(map[string]Latency),
+		faults:  make(map[string][]Fault),
+		timers:  make(map[*time.Timer]bool),
+	}
+	sim.Server.intercept = sim.inject
+	sim.Server.handled = sim.sent
+
+	return sim
+}
+
+// Seed 设置随机数种子，用于重现相同的延迟、故障和下发结果
+func (sim *Simulator) Seed(seed int64) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	sim.rand.Seed(seed)
+}
+
+// SetLatency 设置接口 api 的响应延迟，api 为 AnyAPI 时作用于没有单独设置的接口
+// l 为空时取消设置
+func (sim *Simulator) SetLatency(api string, l Latency) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	if l == nil {
+		delete(sim.latency, api)
+		return
+	}
+	sim.latency[api] = l
+}
+
+// SetFaults 设置接口 api 的故障，替换之前的设置，api 为 AnyAPI 时作用于所有接口
+// 每次请求最多触发一个故障，先检查接口单独设置的故障，再检查 AnyAPI 的故障
+func (sim *Simulator) SetFaults(api string, faults ...Fault) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	if len(faults) == 0 {
+		delete(sim.faults, api)
+		return
+	}
+	sim.faults[api] = faults
+}
+
+// Close 停止生成下发状态和回复，并关闭服务器
+func (sim *Simulator) Close() {
+	sim.mu.Lock()
+	sim.closed = true
+	for t := range sim.timers {
+		t.Stop()
+	}
+	sim.timers = nil
+	sim.mu.Unlock()
+
+	sim.Server.Close()
+}
+
+// inject 等待响应延迟，触发故障时写入响应并返回 true
+func (sim *Simulator) inject(w http.ResponseWriter, r *http.Request, api string) bool {
+	sim.mu.Lock()
+	var delay time.Duration
+	l, ok := sim.latency[api]
+	if !ok {
+		l = sim.latency[AnyAPI]
+	}
+	if l != nil {
+		delay = l.Delay(sim.rand)
+	}
+	f, faulted := sim.pick(api)
+	sim.mu.Unlock()
+
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return true
+		}
+	}
+
+	if !faulted {
+		return false
+	}
+	f.write(w, api)
+
+	return true
+}
+
+// pick 按概率选取本次请求触发的故障，需要持有锁
+func (sim *Simulator) pick(api string) (Fault, bool) {
+	u := sim.rand.Float64()
+	for _, key := range []string{api, AnyAPI} {
+		for _, f := range sim.faults[key] {
+			u -= f.Rate
+			if u < 0 {
+				return f, true
+			}
+		}
+	}
+
+	return Fault{}, false
+}
+
+// sent 为发送成功的短信安排下发状态和回复
+func (sim *Simulator) sent(api string, body []byte, res interface{}) {
+	var req struct {
+		Extend string `json:"extend"`
+	}
+	json.Unmarshal(body, &req)
+
+	switch res := res.(type) {
+	case qcloudsms.SMSResult:
+		var single qcloudsms.SMSSingleReq
+		json.Unmarshal(body, &single)
+		sim.deliver(single.Tel, res.Sid, req.Extend)
+	case qcloudsms.SMSMultiResult:
+		for _, d := range res.Detail {
+			if d.Result == qcloudsms.SUCCESS {
+				sim.deliver(qcloudsms.SMSTel{Nationcode: d.Nationcode, Mobile: d.Mobile}, d.Sid, req.Extend)
+			}
+		}
+	}
+}
+
+// deliver 按设置的比例和延迟生成一条短信的下发状态和回复
+func (sim *Simulator) deliver(tel qcloudsms.SMSTel, sid, extend string) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	if sim.closed {
+		return
+	}
+
+	if sim.rand.Float64() < sim.ReportRate {
+		st := qcloudsms.SMSStatusResult{
+			Nationcode:   tel.Nationcode,
+			Mobile:       tel.Mobile,
+			ReportStatus: qcloudsms.ReportSuccess,
+			Errmsg:       "DELIVRD",
+			Description:  "用户短信送达成功",
+			Sid:          sid,
+		}
+		if sim.rand.Float64() < sim.ReportFailRate {
+			codes := sim.ReportFailCodes
+			if len(codes) == 0 {
+				codes = []string{"MK:0005"}
+			}
+			st.ReportStatus = qcloudsms.ReportFail
+			st.Errmsg = codes[sim.rand.Intn(len(codes))]
+			st.Description = ""
+			if ce, ok := qcloudsms.LookupCarrierError(st.Errmsg); ok {
+				st.Description = ce.Description
+			}
+		}
+
+		sim.after(sim.ReportDelay, func() {
+			st.UserReceiveTime = qcloudsms.ReportTime{Time: time.Now()}
+			sim.PushStatus(st)
+			sim.push([]qcloudsms.SMSStatusResult{st})
+		})
+	}
+
+	if sim.rand.Float64() < sim.ReplyRate {
+		text := sim.ReplyText
+		if text == "" {
+			text = "收到"
+		}
+		rp := qcloudsms.SMSReplyResult{
+			Nationcode: tel.Nationcode,
+			Mobile:     tel.Mobile,
+			Text:       text,
+			Extend:     extend,
+		}
+
+		sim.after(sim.ReplyDelay, func() {
+			rp.Time = time.Now().Unix()
+			sim.PushReply(rp)
+			sim.push(rp)
+		})
+	}
+}
+
+// after 在延迟 l 之后执行 f，需要持有锁
+func (sim *Simulator) after(l Latency, f func()) {
+	var delay time.Duration
+	if l != nil {
+		delay = l.Delay(sim.rand)
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(delay, func() {
+		sim.mu.Lock()
+		if sim.closed {
+			sim.mu.Unlock()
+			return
+		}
+		delete(sim.timers, t)
+		sim.mu.Unlock()
+
+		f()
+	})
+	sim.timers[t] = true
+}
+
+// push 将下发状态或回复推送到 CallbackURL
+func (sim *Simulator) push(v interface{}) {
+	if sim.CallbackURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		sim.error(err)
+		return
+	}
+
+	client := sim.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Post(sim.CallbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		sim.error(err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var res qcloudsms.CallbackResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		sim.error(fmt.Errorf("推送响应解析失败: %v", err))
+		return
+	}
+	if resp.StatusCode != http.StatusOK || res.Result != 0 {
+		sim.error(fmt.Errorf("推送失败: %d %d %s", resp.StatusCode, res.Result, res.Errmsg))
+	}
+}
+
+func (sim *Simulator) error(err error) {
+	if sim.OnError != nil {
+		sim.OnError(err)
+	}
+}
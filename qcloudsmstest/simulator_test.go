@@ -0,0 +1,184 @@
+package qcloudsmstest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+)
+
+func TestSimulatorFaults(t *testing.T) {
+	sim := NewSimulator("1400", "key")
+	defer sim.Close()
+	client := sim.NewClient()
+	req := qcloudsms.SMSSingleReq{Tel: tel, Msg: "test"}
+
+	sim.SetFaults(qcloudsms.SENDSMS, ResultFault(1, qcloudsms.ErrFrequencyLimit))
+	if _, err := client.SendSMSSingle(req); !errors.Is(err, qcloudsms.ErrFrequencyLimit) {
+		t.Errorf("result fault: err = %v, want ErrFrequencyLimit", err)
+	}
+
+	sim.SetFaults(qcloudsms.SENDSMS, HTTPFault(1, http.StatusBadGateway))
+	_, err := client.SendSMSSingle(req)
+	var apiErr *qcloudsms.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("HTTP fault: err = %v, want status 502", err)
+	}
+
+	sim.SetFaults(qcloudsms.SENDSMS, MalformedFault(1))
+	var decodeErr *qcloudsms.DecodeError
+	if _, err := client.SendSMSSingle(req); !errors.As(err, &decodeErr) {
+		t.Errorf("malformed fault: err = %v, want *DecodeError", err)
+	}
+
+	// AnyAPI 的故障作用于所有接口
+	sim.SetFaults(qcloudsms.SENDSMS)
+	sim.SetFaults(AnyAPI, ResultFault(1, qcloudsms.ErrInsufficientBalance))
+	if _, err := client.PullStatus(qcloudsms.PullStatusReq{Max: 10}); !errors.Is(err, qcloudsms.ErrInsufficientBalance) {
+		t.Errorf("AnyAPI fault: err = %v, want ErrInsufficientBalance", err)
+	}
+
+	sim.SetFaults(AnyAPI)
+	if _, err := client.SendSMSSingle(req); err != nil {
+		t.Errorf("faults cleared: err = %v", err)
+	}
+}
+
+func TestSimulatorFaultRate(t *testing.T) {
+	sim := NewSimulator("1400", "key")
+	defer sim.Close()
+	sim.Seed(1)
+	sim.SetFaults(qcloudsms.SENDSMS, ResultFault(0.3, qcloudsms.ErrFrequencyLimit))
+	client := sim.NewClient()
+
+	failed := 0
+	for i := 0; i < 200; i++ {
+		if _, err := client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, Msg: "test"}); err != nil {
+			failed++
+		}
+	}
+	if failed < 30 || failed > 90 {
+		t.Errorf("%d of 200 requests failed, want about 60", failed)
+	}
+}
+
+func TestSimulatorLatency(t *testing.T) {
+	sim := NewSimulator("1400", "key")
+	defer sim.Close()
+	client := sim.NewClient()
+
+	sim.SetLatency(AnyAPI, FixedLatency(50*time.Millisecond))
+	start := time.Now()
+	if _, err := client.PullStatus(qcloudsms.PullStatusReq{Max: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("request took %v, want at least 50ms", d)
+	}
+
+	sim.SetLatency(AnyAPI, FixedLatency(time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.PullStatusContext(ctx, qcloudsms.PullStatusReq{Max: 10}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	sim.SetLatency(AnyAPI, nil)
+}
+
+func TestSimulatorReports(t *testing.T) {
+	sim := NewSimulator("1400", "key")
+	defer sim.Close()
+	sim.ReportRate = 1
+	sim.ReportDelay = FixedLatency(10 * time.Millisecond)
+	sim.ReportFailRate = 1
+	sim.ReportFailCodes = []string{"DB:0141"}
+	sim.ReplyRate = 1
+	sim.ReplyText = "TD"
+	client := sim.NewClient()
+
+	res, err := client.SendSMSSingle(qcloudsms.SMSSingleReq{Tel: tel, Msg: "test", Extend: "12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var st qcloudsms.PullStatusResult
+	deadline := time.Now().Add(2 * time.Second)
+	for st.Count == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		st, err = client.PullStatus(qcloudsms.PullStatusReq{Max: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st.Count != 1 {
+		t.Fatalf("PullStatus = %+v, want 1 report", st)
+	}
+	s := st.Data[0]
+	if s.Sid != res.Sid || s.Success() || s.Category() != qcloudsms.CategoryBlacklist {
+		t.Errorf("report = %+v", s)
+	}
+
+	rp, err := client.PullReply(qcloudsms.PullStatusReq{Max: 10})
+	if err != nil || rp.Count != 1 || rp.Data[0].Text != "TD" || rp.Data[0].Extend != "12" {
+		t.Errorf("PullReply = %+v, %v", rp, err)
+	}
+}
+
+func TestSimulatorCallback(t *testing.T) {
+	var mu sync.Mutex
+	var statuses []qcloudsms.SMSStatusResult
+	var replies []qcloudsms.ReplyEvent
+	done := make(chan struct{}, 4)
+
+	cb := httptest.NewServer(&qcloudsms.CallbackMux{
+		Status: func(ctx context.Context, s qcloudsms.SMSStatusResult) error {
+			mu.Lock()
+			statuses = append(statuses, s)
+			mu.Unlock()
+			done <- struct{}{}
+			return nil
+		},
+		Reply: func(ctx context.Context, r qcloudsms.ReplyEvent) error {
+			mu.Lock()
+			replies = append(replies, r)
+			mu.Unlock()
+			done <- struct{}{}
+			return nil
+		},
+	})
+	defer cb.Close()
+
+	sim := NewSimulator("1400", "key")
+	defer sim.Close()
+	sim.ReportRate = 1
+	sim.ReplyRate = 1
+	sim.CallbackURL = cb.URL
+	sim.OnError = func(err error) { t.Error(err) }
+	client := sim.NewClient()
+
+	if _, err := client.SendSMSMulti(qcloudsms.SMSMultiReq{
+		Tel: []qcloudsms.SMSTel{tel, {Nationcode: "86", Mobile: "13800000001"}},
+		Msg: "test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %d callbacks, want 4", i)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(statuses) != 2 || len(replies) != 2 {
+		t.Errorf("got %d statuses and %d replies, want 2 each", len(statuses), len(replies))
+	}
+}
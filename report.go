@@ -0,0 +1,203 @@
+package qcloudsms
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportStatus 短信下发状态
+type ReportStatus string
+
+const (
+	// ReportSuccess 用户接收成功
+	ReportSuccess ReportStatus = "SUCCESS"
+	// ReportFail 用户接收失败
+	ReportFail ReportStatus = "FAIL"
+)
+
+// reportTimeLayout 下发状态中时间的格式
+const reportTimeLayout = "2006-01-02 15:04:05"
+
+// reportTimeZone 下发状态中时间使用的时区，东八区
+var reportTimeZone = time.FixedZone("CST", 8*3600)
+
+// ReportTime 下发状态中的时间，格式为 2006-01-02 15:04:05，东八区
+//
+// 解析时不会返回错误，避免一条格式异常的记录导致整批数据无法解析；
+// 无法识别的格式 Time 为零值，原始内容保存在 Raw 中，错误保存在 Err 中
+type ReportTime struct {
+	time.Time
+
+	// 原始内容
+	Raw string
+	// 解析错误，为 nil 时 Time 有效
+	Err error
+}
+
+// UnmarshalJSON 解析下发状态中的时间，空字符串解析为零值
+// 除 2006-01-02 15:04:05 外，也接受 RFC3339 格式和 Unix 时间戳
+func (t *ReportTime) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	*t = ReportTime{Raw: s}
+	if s == "" || s == "null" {
+		return nil
+	}
+
+	if v, err := time.ParseInLocation(reportTimeLayout, s, reportTimeZone); err == nil {
+		t.Time = v
+		return nil
+	}
+	if v, err := time.Parse(time.RFC3339, s); err == nil {
+		t.Time = v
+		return nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t.Time = time.Unix(n, 0).In(reportTimeZone)
+		return nil
+	}
+
+	t.Err = fmt.Errorf("无法解析的时间 %q", s)
+
+	return nil
+}
+
+// MarshalJSON 按下发状态中的格式输出时间，零值输出为空字符串
+// 解析失败的时间原样输出 Raw
+func (t ReportTime) MarshalJSON() ([]byte, error) {
+	if t.Err != nil {
+		return json.Marshal(t.Raw)
+	}
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+
+	return []byte(`"` + t.In(reportTimeZone).Format(reportTimeLayout) + `"`), nil
+}
+
+// ErrorCategory 下发失败的原因分类
+// 0 - 4 与 StatusResult 中 StatusFail0 - StatusFail4 的分类一致，
+// CategoryContentBlocked 在回执数据统计中没有单独的分类，计入 StatusFail0
+type ErrorCategory int
+
+const (
+	// CategoryUnknown 无法识别的错误
+	CategoryUnknown ErrorCategory = -1
+	// CategoryCarrier 运营商内部错误，对应 StatusFail0
+	CategoryCarrier ErrorCategory = 0
+	// CategoryInvalidNumber 号码无效或空号，对应 StatusFail1
+	CategoryInvalidNumber ErrorCategory = 1
+	// CategoryPoweredOff 停机、关机等，对应 StatusFail2
+	CategoryPoweredOff ErrorCategory = 2
+	// CategoryBlacklist 黑名单，对应 StatusFail3
+	CategoryBlacklist ErrorCategory = 3
+	// CategoryRateLimit 运营商频率限制，对应 StatusFail4
+	CategoryRateLimit ErrorCategory = 4
+	// CategoryContentBlocked 内容被拦截，回执数据统计中计入 StatusFail0
+	CategoryContentBlocked ErrorCategory = 5
+)
+
+var categoryNames = map[ErrorCategory]string{
+	CategoryUnknown:        "未知错误",
+	CategoryCarrier:        "运营商内部错误",
+	CategoryInvalidNumber:  "号码无效或空号",
+	CategoryPoweredOff:     "停机、关机等",
+	CategoryBlacklist:      "黑名单",
+	CategoryRateLimit:      "运营商频率限制",
+	CategoryContentBlocked: "内容被拦截",
+}
+
+func (c ErrorCategory) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+
+	return categoryNames[CategoryUnknown]
+}
+
+// CarrierError 运营商或腾讯云返回的下发错误码说明
+type CarrierError struct {
+	Code        string
+	Category    ErrorCategory
+	Description string
+}
+
+// carrierErrors 常见的下发错误码
+// 同一错误码在不同运营商、不同通道下的含义可能有差异，此处为常见含义
+var carrierErrors = map[string]CarrierError{
+	"UNDELIV": {"UNDELIV", CategoryCarrier, "短信无法送达"},
+	"EXPIRED": {"EXPIRED", CategoryPoweredOff, "短信过期未送达，通常为长时间关机或不在服务区"},
+	"REJECTD": {"REJECTD", CategoryContentBlocked, "短信被运营商拒绝"},
+	"DELETED": {"DELETED", CategoryCarrier, "短信被运营商删除"},
+	"UNKNOWN": {"UNKNOWN", CategoryCarrier, "运营商返回未知状态"},
+	"MK:0001": {"MK:0001", CategoryInvalidNumber, "空号"},
+	"MK:0005": {"MK:0005", CategoryPoweredOff, "关机或不在服务区"},
+	"MK:0012": {"MK:0012", CategoryPoweredOff, "停机"},
+	"MI:0013": {"MI:0013", CategoryPoweredOff, "停机"},
+	"MI:0024": {"MI:0024", CategoryInvalidNumber, "空号"},
+	"MN:0001": {"MN:0001", CategoryInvalidNumber, "空号"},
+	"DB:0141": {"DB:0141", CategoryBlacklist, "号码在运营商黑名单中"},
+	"BLACK":   {"BLACK", CategoryBlacklist, "号码在黑名单中"},
+	"KEYWORD": {"KEYWORD", CategoryContentBlocked, "内容包含敏感词"},
+	"LIMIT":   {"LIMIT", CategoryRateLimit, "命中运营商频率限制"},
+}
+
+// descriptionKeywords 根据状态描述判断分类的关键字，用于错误码不在 carrierErrors 中的情况
+var descriptionKeywords = []struct {
+	keyword  string
+	category ErrorCategory
+}{
+	{"空号", CategoryInvalidNumber},
+	{"无效", CategoryInvalidNumber},
+	{"关机", CategoryPoweredOff},
+	{"停机", CategoryPoweredOff},
+	{"黑名单", CategoryBlacklist},
+	{"频率", CategoryRateLimit},
+	{"流控", CategoryRateLimit},
+	{"敏感词", CategoryContentBlocked},
+	{"关键字", CategoryContentBlocked},
+	{"拦截", CategoryContentBlocked},
+	{"运营商", CategoryCarrier},
+}
+
+// LookupCarrierError 查询下发错误码的说明
+func LookupCarrierError(code string) (CarrierError, bool) {
+	e, ok := carrierErrors[strings.ToUpper(strings.TrimSpace(code))]
+	return e, ok
+}
+
+// Success 返回短信是否下发成功
+func (s SMSStatusResult) Success() bool {
+	return s.ReportStatus == ReportSuccess
+}
+
+// CarrierError 返回下发错误码的说明，下发成功或错误码无法识别时返回 false
+func (s SMSStatusResult) CarrierError() (CarrierError, bool) {
+	if s.Success() {
+		return CarrierError{}, false
+	}
+
+	return LookupCarrierError(s.Errmsg)
+}
+
+// Category 返回下发失败的原因分类
+// 错误码无法识别时根据 Description 判断，仍无法判断或下发成功时返回 CategoryUnknown
+func (s SMSStatusResult) Category() ErrorCategory {
+	if s.Success() {
+		return CategoryUnknown
+	}
+
+	if e, ok := s.CarrierError(); ok {
+		return e.Category
+	}
+
+	for _, k := range descriptionKeywords {
+		if strings.Contains(s.Description, k.keyword) {
+			return k.category
+		}
+	}
+
+	return CategoryUnknown
+}
@@ -0,0 +1,99 @@
+package qcloudsms
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportTimeLenient(t *testing.T) {
+	body := `{"result":0,"errmsg":"OK","count":4,"data":[
+		{"user_receive_time":"2017-03-15 10:10:10","mobile":"1","report_status":"SUCCESS","errmsg":"DELIVRD","sid":"a"},
+		{"user_receive_time":"2017/03/15 10:10:10","mobile":"2","report_status":"SUCCESS","errmsg":"DELIVRD","sid":"b"},
+		{"user_receive_time":1489543810,"mobile":"3","report_status":"SUCCESS","errmsg":"DELIVRD","sid":"c"},
+		{"user_receive_time":"","mobile":"4","report_status":"FAIL","errmsg":"MK:0005","sid":"d"}
+	]}`
+
+	var res PullStatusResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(res.Data) != 4 {
+		t.Fatalf("got %d records, want 4", len(res.Data))
+	}
+
+	want := time.Date(2017, 3, 15, 10, 10, 10, 0, reportTimeZone)
+	if rt := res.Data[0].UserReceiveTime; rt.Err != nil || !rt.Equal(want) {
+		t.Errorf("data[0] = %v, %v, want %v", rt.Time, rt.Err, want)
+	}
+	if rt := res.Data[1].UserReceiveTime; rt.Err == nil || !rt.IsZero() || rt.Raw != "2017/03/15 10:10:10" {
+		t.Errorf("data[1] = %+v, want parse error with raw value", rt)
+	}
+	if rt := res.Data[2].UserReceiveTime; rt.Err != nil || !rt.Equal(want) {
+		t.Errorf("data[2] = %v, %v, want %v", rt.Time, rt.Err, want)
+	}
+	if rt := res.Data[3].UserReceiveTime; rt.Err != nil || !rt.IsZero() {
+		t.Errorf("data[3] = %+v, want zero time", rt)
+	}
+
+	// 解析失败的时间重新编码后保留原始内容，写入日志后可以原样读回
+	b, err := json.Marshal(res.Data[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again SMSStatusResult
+	if err := json.Unmarshal(b, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.UserReceiveTime.Raw != "2017/03/15 10:10:10" {
+		t.Errorf("Raw after round trip = %q", again.UserReceiveTime.Raw)
+	}
+}
+
+func TestLookupCarrierError(t *testing.T) {
+	for code, want := range carrierErrors {
+		for _, c := range []string{code, strings.ToLower(code), " " + code + " "} {
+			e, ok := LookupCarrierError(c)
+			if !ok || e != want {
+				t.Errorf("LookupCarrierError(%q) = %+v, %v, want %+v", c, e, ok, want)
+			}
+		}
+		if want.Code != code || want.Description == "" || want.Category.String() == CategoryUnknown.String() {
+			t.Errorf("carrierErrors[%q] = %+v", code, want)
+		}
+
+		s := SMSStatusResult{ReportStatus: ReportFail, Errmsg: code}
+		if got := s.Category(); got != want.Category {
+			t.Errorf("Category() for %s = %v, want %v", code, got, want.Category)
+		}
+	}
+
+	if _, ok := LookupCarrierError("NOPE"); ok {
+		t.Error("LookupCarrierError(NOPE) found an entry")
+	}
+}
+
+func TestCategoryDescription(t *testing.T) {
+	for _, k := range descriptionKeywords {
+		s := SMSStatusResult{ReportStatus: ReportFail, Errmsg: "X:0000", Description: "用户" + k.keyword + "，发送失败"}
+		if got := s.Category(); got != k.category {
+			t.Errorf("Category() for %q = %v, want %v", s.Description, got, k.category)
+		}
+	}
+
+	tests := []struct {
+		s    SMSStatusResult
+		want ErrorCategory
+	}{
+		{SMSStatusResult{ReportStatus: ReportSuccess, Errmsg: "DELIVRD"}, CategoryUnknown},
+		// 错误码优先于描述
+		{SMSStatusResult{ReportStatus: ReportFail, Errmsg: "DB:0141", Description: "空号"}, CategoryBlacklist},
+		{SMSStatusResult{ReportStatus: ReportFail, Errmsg: "X:0000", Description: "其他原因"}, CategoryUnknown},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Category(); got != tt.want {
+			t.Errorf("Category() for %+v = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
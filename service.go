@@ -0,0 +1,86 @@
+package qcloudsms
+
+import (
+	"context"
+)
+
+// SMSSender 发送短信的接口
+type SMSSender interface {
+	SendSMSSingle(ss SMSSingleReq) (SMSResult, error)
+	SendSMSSingleContext(ctx context.Context, ss SMSSingleReq) (SMSResult, error)
+	SendSMSMulti(sms SMSMultiReq) (SMSMultiResult, error)
+	SendSMSMultiContext(ctx context.Context, sms SMSMultiReq) (SMSMultiResult, error)
+	SendSMSBulk(sms SMSMultiReq) (SMSMultiResult, error)
+	SendSMSBulkContext(ctx context.Context, sms SMSMultiReq) (SMSMultiResult, error)
+}
+
+// StatusPuller 拉取短信下发状态和短信回复的接口
+type StatusPuller interface {
+	PullStatus(psr PullStatusReq) (PullStatusResult, error)
+	PullStatusContext(ctx context.Context, psr PullStatusReq) (PullStatusResult, error)
+	PullReply(psr PullStatusReq) (PullReplyResult, error)
+	PullReplyContext(ctx context.Context, psr PullStatusReq) (PullReplyResult, error)
+	GetStatusForMobile(smr StatusMobileReq) (StatusMobileResult, error)
+	GetStatusForMobileContext(ctx context.Context, smr StatusMobileReq) (StatusMobileResult, error)
+	GetReplyForMobile(smr StatusMobileReq) (StatusReplyResult, error)
+	GetReplyForMobileContext(ctx context.Context, smr StatusMobileReq) (StatusReplyResult, error)
+}
+
+// VoiceSender 发送语音的接口
+type VoiceSender interface {
+	SendVoice(v VoiceReq) (VoiceResult, error)
+	SendVoiceContext(ctx context.Context, v VoiceReq) (VoiceResult, error)
+	VoiceTemplateSend(s SMSVoiceTemplate) (VoiceResult, error)
+	VoiceTemplateSendContext(ctx context.Context, s SMSVoiceTemplate) (VoiceResult, error)
+}
+
+// TemplateManager 管理短信模板的接口
+type TemplateManager interface {
+	GetTemplateByID(id []uint) (TemplateGetResult, error)
+	GetTemplateByIDContext(ctx context.Context, id []uint) (TemplateGetResult, error)
+	GetTemplateByPage(offset, max uint) (TemplateGetResult, error)
+	GetTemplateByPageContext(ctx context.Context, offset, max uint) (TemplateGetResult, error)
+	NewTemplate(t TemplateNew) (TemplateResult, error)
+	NewTemplateContext(ctx context.Context, t TemplateNew) (TemplateResult, error)
+	ModTemplate(t TemplateNew) (TemplateResult, error)
+	ModTemplateContext(ctx context.Context, t TemplateNew) (TemplateResult, error)
+	DelTemplate(id []uint) (TemplateResult, error)
+	DelTemplateContext(ctx context.Context, id []uint) (TemplateResult, error)
+}
+
+// SignManager 管理短信签名的接口
+type SignManager interface {
+	NewSign(s SignReq) (SignResult, error)
+	NewSignContext(ctx context.Context, s SignReq) (SignResult, error)
+	ModSign(s SignReq) (SignResult, error)
+	ModSignContext(ctx context.Context, s SignReq) (SignResult, error)
+	GetSign(signid []uint) (SignStatusResult, error)
+	GetSignContext(ctx context.Context, signid []uint) (SignStatusResult, error)
+	DelSign(signid []uint) (SignResult, error)
+	DelSignContext(ctx context.Context, signid []uint) (SignResult, error)
+}
+
+// StatsQuerier 查询发送数据统计和回执数据统计的接口
+type StatsQuerier interface {
+	GetStatus(begin, end uint32) (StatusResult, error)
+	GetStatusContext(ctx context.Context, begin, end uint32) (StatusResult, error)
+	GetSendStatus(begin, end uint32) (SendStatusResult, error)
+	GetSendStatusContext(ctx context.Context, begin, end uint32) (SendStatusResult, error)
+}
+
+// Service 包含全部业务接口，*QcloudSMS 实现了此接口
+//
+// 调用方可以依赖 Service 或其中的单个接口，测试时替换为 qcloudsmstest.Mock
+type Service interface {
+	SMSSender
+	StatusPuller
+	VoiceSender
+	TemplateManager
+	SignManager
+	StatsQuerier
+}
+
+var (
+	_ QcloudClient = (*QcloudSMS)(nil)
+	_ Service      = (*QcloudSMS)(nil)
+)
@@ -0,0 +1,64 @@
+package qcloudsms_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	qcloudsms "github.com/qichengzx/qcloudsms_go"
+	"github.com/qichengzx/qcloudsms_go/qcloudsmstest"
+)
+
+func TestStatusPollerMock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pulls := 0
+	m := &qcloudsmstest.Mock{
+		PullStatusFunc: func(ctx context.Context, psr qcloudsms.PullStatusReq) (qcloudsms.PullStatusResult, error) {
+			pulls++
+			switch pulls {
+			case 1:
+				return qcloudsms.PullStatusResult{}, qcloudsms.ErrFrequencyLimit
+			case 2:
+				// 返回条数等于 Max，应立即继续拉取
+				return qcloudsms.PullStatusResult{Count: 2, Data: []qcloudsms.SMSStatusResult{{Sid: "a"}, {Sid: "b"}}}, nil
+			case 3:
+				return qcloudsms.PullStatusResult{Count: 1, Data: []qcloudsms.SMSStatusResult{{Sid: "c"}}}, nil
+			}
+			cancel()
+			return qcloudsms.PullStatusResult{}, nil
+		},
+	}
+
+	var sids []string
+	var logs bytes.Buffer
+	p := &qcloudsms.StatusPoller{
+		Client: m,
+		Status: func(ctx context.Context, s qcloudsms.SMSStatusResult) error {
+			sids = append(sids, s.Sid)
+			return nil
+		},
+		Max:      2,
+		Interval: time.Millisecond,
+		Logger:   log.New(&logs, "", 0),
+	}
+	if err := p.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	if len(sids) != 3 || sids[0] != "a" || sids[2] != "c" {
+		t.Errorf("handled %v, want [a b c]", sids)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("Poll Error")) {
+		t.Errorf("log = %q, want pull error", logs.String())
+	}
+	for _, c := range m.Calls() {
+		if c.Method != "PullStatus" {
+			t.Errorf("unexpected call %s", c.Method)
+		}
+	}
+}
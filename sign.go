@@ -1,7 +1,7 @@
 package qcloudsms
 
 import (
-	"encoding/json"
+	"context"
 )
 
 // SignReq 添加/修改签名的请求结构
@@ -57,20 +57,20 @@ type SignStatusResult struct {
 //
 // https://cloud.tencent.com/document/product/382/6038
 func (c *QcloudSMS) NewSign(s SignReq) (SignResult, error) {
-	c = c.NewSig("").NewURL(ADDSIGN)
+	return c.NewSignContext(context.Background(), s)
+}
 
-	s.Time = c.ReqTime
-	s.Sig = c.Sig
+// NewSignContext 与 NewSign 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) NewSignContext(ctx context.Context, s SignReq) (SignResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(ADDSIGN)
 
-	var res SignResult
-	resp, err := c.NewRequest(s)
-	if err != nil {
-		return res, err
-	}
+	s.Time = r.ReqTime
+	s.Sig = r.Sig
 
-	json.Unmarshal([]byte(resp), &res)
+	var res SignResult
+	err := r.call(ctx, s, &res)
 
-	return res, nil
+	return res, err
 }
 
 // ModSign 修改签名
@@ -78,20 +78,20 @@ func (c *QcloudSMS) NewSign(s SignReq) (SignResult, error) {
 //
 // https://cloud.tencent.com/document/product/382/8650
 func (c *QcloudSMS) ModSign(s SignReq) (SignResult, error) {
-	c = c.NewSig("").NewURL(MODSIGN)
+	return c.ModSignContext(context.Background(), s)
+}
 
-	s.Time = c.ReqTime
-	s.Sig = c.Sig
+// ModSignContext 与 ModSign 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) ModSignContext(ctx context.Context, s SignReq) (SignResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(MODSIGN)
 
-	var res SignResult
-	resp, err := c.NewRequest(s)
-	if err != nil {
-		return res, err
-	}
+	s.Time = r.ReqTime
+	s.Sig = r.Sig
 
-	json.Unmarshal([]byte(resp), &res)
+	var res SignResult
+	err := r.call(ctx, s, &res)
 
-	return res, nil
+	return res, err
 }
 
 // GetSign 短信签名状态查询
@@ -99,23 +99,23 @@ func (c *QcloudSMS) ModSign(s SignReq) (SignResult, error) {
 //
 // https://cloud.tencent.com/document/product/382/6040
 func (c *QcloudSMS) GetSign(signid []uint) (SignStatusResult, error) {
-	c = c.NewSig("").NewURL(GETSIGN)
+	return c.GetSignContext(context.Background(), signid)
+}
+
+// GetSignContext 与 GetSign 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetSignContext(ctx context.Context, signid []uint) (SignStatusResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(GETSIGN)
 
 	var s = SignDelGet{
 		SignID: signid,
-		Time:   c.ReqTime,
-		Sig:    c.Sig,
+		Time:   r.ReqTime,
+		Sig:    r.Sig,
 	}
 
 	var res SignStatusResult
-	resp, err := c.NewRequest(s)
-	if err != nil {
-		return res, err
-	}
-
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, s, &res)
 
-	return res, nil
+	return res, err
 }
 
 // DelSign 删除短信签名
@@ -123,21 +123,21 @@ func (c *QcloudSMS) GetSign(signid []uint) (SignStatusResult, error) {
 //
 // https://cloud.tencent.com/document/product/382/6039
 func (c *QcloudSMS) DelSign(signid []uint) (SignResult, error) {
-	c = c.NewSig("").NewURL(DELSIGN)
+	return c.DelSignContext(context.Background(), signid)
+}
+
+// DelSignContext 与 DelSign 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) DelSignContext(ctx context.Context, signid []uint) (SignResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(DELSIGN)
 
 	var s = SignDelGet{
-		Time:   c.ReqTime,
-		Sig:    c.Sig,
+		Time:   r.ReqTime,
+		Sig:    r.Sig,
 		SignID: signid,
 	}
 
 	var res SignResult
-	resp, err := c.NewRequest(s)
-	if err != nil {
-		return res, err
-	}
-
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, s, &res)
 
-	return res, nil
+	return res, err
 }
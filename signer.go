@@ -0,0 +1,104 @@
+package qcloudsms
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SIGMAXAGE Signer 校验时默认允许的请求时间与当前时间的最大差值
+const SIGMAXAGE = 5 * time.Minute
+
+var (
+	// ErrSigMismatch sig 与请求内容不匹配
+	ErrSigMismatch = errors.New("sig 校验失败")
+	// ErrSigExpired 请求时间与当前时间相差过大
+	ErrSigExpired = errors.New("请求时间已过期")
+	// ErrSigReplayed 相同的请求已经校验过
+	ErrSigReplayed = errors.New("重复的请求")
+)
+
+// Signer 计算和校验请求的 sig
+//
+// sig = sha256("appkey=xxx&random=xxx&time=xxx&mobile=xxx")，
+// 单发为一个号码，群发为逗号连接的多个号码，模板、签名、统计等接口不包含 mobile
+type Signer struct {
+	AppKey string
+	// 校验时允许的请求时间与当前时间的最大差值，默认为 SIGMAXAGE
+	MaxAge time.Duration
+	// 校验时使用的当前时间，默认为系统时间
+	Clock Clock
+
+	mu   sync.Mutex
+	seen *recentSet
+}
+
+// NewSigner 返回使用 appkey 的 Signer
+func NewSigner(appkey string) *Signer {
+	return &Signer{AppKey: appkey}
+}
+
+// Sign 计算 sig
+// mobiles 为空时不包含 mobile，多个号码以逗号连接
+func (s *Signer) Sign(random string, t int64, mobiles ...string) string {
+	var sigContent = "appkey=" + s.AppKey + "&random=" + random + "&time=" + strconv.FormatInt(t, 10)
+
+	if m := strings.Join(mobiles, ","); len(m) > 0 {
+		sigContent += "&mobile=" + m
+	}
+	h := sha256.Sum256([]byte(sigContent))
+
+	return hex.EncodeToString(h[:])
+}
+
+// Verify 校验 sig
+//
+// 请求时间与当前时间相差超过 MaxAge 时返回 ErrSigExpired，sig 不匹配时返回 ErrSigMismatch，
+// 在 MaxAge 时间内已经校验通过的相同请求再次校验时返回 ErrSigReplayed
+func (s *Signer) Verify(sig, random string, t int64, mobiles ...string) error {
+	maxAge := s.MaxAge
+	if maxAge <= 0 {
+		maxAge = SIGMAXAGE
+	}
+
+	now := time.Now()
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	}
+
+	age := now.Sub(time.Unix(t, 0))
+	if age > maxAge || age < -maxAge {
+		return ErrSigExpired
+	}
+
+	expected := s.Sign(random, t, mobiles...)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(sig))) != 1 {
+		return ErrSigMismatch
+	}
+
+	if !s.recent(maxAge).add(expected, now, 0) {
+		return ErrSigReplayed
+	}
+
+	return nil
+}
+
+// recent 返回记录已校验请求的 recentSet
+// 超出 2 倍 MaxAge 的请求会因时间过期被拒绝，不再需要记录；
+// MaxAge 修改后按新的值调整时间窗口，已经记录的请求仍然保留
+func (s *Signer) recent(maxAge time.Duration) *recentSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen == nil {
+		s.seen = newRecentSet(2 * maxAge)
+	}
+	s.seen.setWindow(2 * maxAge)
+
+	return s.seen
+}
@@ -0,0 +1,121 @@
+package qcloudsms
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignerSign(t *testing.T) {
+	s := NewSigner("key")
+
+	tests := []struct {
+		mobiles []string
+		content string
+	}{
+		{nil, "appkey=key&random=123456&time=1500000000"},
+		{[]string{""}, "appkey=key&random=123456&time=1500000000"},
+		{[]string{"13800000000"}, "appkey=key&random=123456&time=1500000000&mobile=13800000000"},
+		{[]string{"13800000000", "13800000001"}, "appkey=key&random=123456&time=1500000000&mobile=13800000000,13800000001"},
+		{[]string{"13800000000,13800000001"}, "appkey=key&random=123456&time=1500000000&mobile=13800000000,13800000001"},
+	}
+	for _, tt := range tests {
+		h := sha256.Sum256([]byte(tt.content))
+		if got := s.Sign("123456", 1500000000, tt.mobiles...); got != hex.EncodeToString(h[:]) {
+			t.Errorf("Sign(%v) = %s, want sha256(%s)", tt.mobiles, got, tt.content)
+		}
+	}
+}
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	ts := now.Unix()
+	single := []string{"13800000000"}
+	multi := []string{"13800000000", "13800000001"}
+
+	tests := []struct {
+		name    string
+		sig     string
+		random  string
+		t       int64
+		mobiles []string
+		want    error
+	}{
+		{"single", NewSigner("key").Sign("1", ts, single...), "1", ts, single, nil},
+		{"upper case", strings.ToUpper(NewSigner("key").Sign("2", ts, single...)), "2", ts, single, nil},
+		{"multi", NewSigner("key").Sign("3", ts, multi...), "3", ts, multi, nil},
+		{"no mobile", NewSigner("key").Sign("4", ts), "4", ts, nil, nil},
+		{"wrong key", NewSigner("other").Sign("5", ts, single...), "5", ts, single, ErrSigMismatch},
+		{"wrong mobile", NewSigner("key").Sign("6", ts, single...), "6", ts, []string{"13800000001"}, ErrSigMismatch},
+		{"multi reordered", NewSigner("key").Sign("7", ts, multi...), "7", ts, []string{multi[1], multi[0]}, ErrSigMismatch},
+		{"mobile missing", NewSigner("key").Sign("8", ts, single...), "8", ts, nil, ErrSigMismatch},
+		{"past", NewSigner("key").Sign("9", ts-301, single...), "9", ts - 301, single, ErrSigExpired},
+		{"future", NewSigner("key").Sign("10", ts+301, single...), "10", ts + 301, single, ErrSigExpired},
+		{"edge", NewSigner("key").Sign("11", ts-300, single...), "11", ts - 300, single, nil},
+	}
+
+	s := NewSigner("key")
+	s.Clock = fixedClock{now}
+	for _, tt := range tests {
+		if err := s.Verify(tt.sig, tt.random, tt.t, tt.mobiles...); err != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSignerReplay(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	s := NewSigner("key")
+	s.Clock = fixedClock{now}
+	sig := s.Sign("123456", now.Unix(), "13800000000")
+
+	if err := s.Verify(sig, "123456", now.Unix(), "13800000000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(sig, "123456", now.Unix(), "13800000000"); err != ErrSigReplayed {
+		t.Errorf("replay: err = %v, want ErrSigReplayed", err)
+	}
+
+	// MaxAge 内的任何时间重放都会被拒绝
+	s.Clock = fixedClock{now.Add(SIGMAXAGE)}
+	if err := s.Verify(sig, "123456", now.Unix(), "13800000000"); err != ErrSigReplayed {
+		t.Errorf("replay at MaxAge: err = %v, want ErrSigReplayed", err)
+	}
+
+	// 超出 MaxAge 后请求已过期
+	s.Clock = fixedClock{now.Add(SIGMAXAGE + time.Second)}
+	if err := s.Verify(sig, "123456", now.Unix(), "13800000000"); err != ErrSigExpired {
+		t.Errorf("after MaxAge: err = %v, want ErrSigExpired", err)
+	}
+
+	// 失败的校验不记录，sig 正确的请求之后仍然可以通过
+	s.Clock = fixedClock{now}
+	other := s.Sign("654321", now.Unix())
+	if err := s.Verify("bad", "654321", now.Unix()); err != ErrSigMismatch {
+		t.Fatalf("err = %v, want ErrSigMismatch", err)
+	}
+	if err := s.Verify(other, "654321", now.Unix()); err != nil {
+		t.Errorf("err = %v after a failed attempt", err)
+	}
+}
+
+func TestSignerMaxAgeChange(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	s := NewSigner("key")
+	s.MaxAge = time.Minute
+	s.Clock = fixedClock{now}
+	sig := s.Sign("123456", now.Unix())
+
+	if err := s.Verify(sig, "123456", now.Unix()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 放宽 MaxAge 后，原来 2 分钟的记录窗口也随之放宽，重放仍然被拒绝
+	s.MaxAge = 10 * time.Minute
+	s.Clock = fixedClock{now.Add(5 * time.Minute)}
+	if err := s.Verify(sig, "123456", now.Unix()); err != ErrSigReplayed {
+		t.Errorf("err = %v, want ErrSigReplayed", err)
+	}
+}
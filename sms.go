@@ -1,8 +1,8 @@
 package qcloudsms
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"net/http"
 	"strings"
 )
 
@@ -39,30 +39,29 @@ type SMSResult struct {
 	Result uint   `json:"result"`
 	Errmsg string `json:"errmsg"`
 	Ext    string `json:"ext"`
-	Sid    string `json:"sid,omitempty"`
-	Fee    uint   `json:"fee,omitempty"`
+	// 本次发送标识，用于关联下发状态
+	Sid string `json:"sid,omitempty"`
+	// 短信计费的条数
+	Fee uint `json:"fee,omitempty"`
 }
 
-// SendSMSSingle 发送单条短信
-func (c *QcloudSMS) SendSMSSingle(ss SMSSingleReq) (bool, error) {
-	c = c.NewSig(ss.Tel.Mobile).NewURL(SENDSMS)
+// SendSMSSingle 发送单条短信，普通短信和模板短信均使用此方法
+// 返回完整的发送结果，包括 sid 和计费条数 fee
+func (c *QcloudSMS) SendSMSSingle(ss SMSSingleReq) (SMSResult, error) {
+	return c.SendSMSSingleContext(context.Background(), ss)
+}
 
-	ss.Time = c.ReqTime
-	ss.Sig = c.Sig
+// SendSMSSingleContext 与 SendSMSSingle 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) SendSMSSingleContext(ctx context.Context, ss SMSSingleReq) (SMSResult, error) {
+	r := c.NewRequest().NewSig(ss.Tel.Mobile).NewURL(SENDSMS)
 
-	resp, err := c.NewRequest(ss)
-	if err != nil {
-		return false, err
-	}
+	ss.Time = r.ReqTime
+	ss.Sig = r.Sig
 
 	var res SMSResult
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, ss, &res)
 
-	if res.Result == SUCCESS {
-		return true, nil
-	}
-
-	return false, errors.New(res.Errmsg)
+	return res, err
 }
 
 /*
@@ -88,26 +87,81 @@ type SMSMultiReq struct {
 }
 
 // SMSMultiResult 群发短信返回结构
+//
+// result 为 0 只表示请求成功，每个号码的发送结果见 Detail
 type SMSMultiResult struct {
-	Result uint   `json:"result"`
-	Errmsg string `json:"errmsg"`
-	Ext    string `json:"ext"`
-	Detail []struct {
-		Result     uint   `json:"result"`
-		Errmsg     string `json:"errmsg"`
-		Mobile     string `json:"mobile"`
-		Nationcode string `json:"nationcode"`
-		Sid        string `json:"sid,omitempty"`
-		Fee        uint   `json:"fee,omitempty"`
-	} `json:"detail"`
+	Result uint             `json:"result"`
+	Errmsg string           `json:"errmsg"`
+	Ext    string           `json:"ext"`
+	Detail []SMSMultiDetail `json:"detail"`
+}
+
+// SMSMultiDetail 群发短信中单个号码的发送结果
+type SMSMultiDetail struct {
+	Result     uint   `json:"result"`
+	Errmsg     string `json:"errmsg"`
+	Mobile     string `json:"mobile"`
+	Nationcode string `json:"nationcode"`
+	Sid        string `json:"sid,omitempty"`
+	Fee        uint   `json:"fee,omitempty"`
+}
+
+// Tel 返回该结果对应的号码，可用于重新发送
+func (d SMSMultiDetail) Tel() SMSTel {
+	return SMSTel{Nationcode: d.Nationcode, Mobile: d.Mobile}
+}
+
+// Err 返回该号码的发送错误，发送成功时返回 nil
+func (d SMSMultiDetail) Err() error {
+	if d.Result == SUCCESS {
+		return nil
+	}
+
+	return &APIError{
+		API:        MULTISMS,
+		StatusCode: http.StatusOK,
+		Result:     d.Result,
+		Errmsg:     d.Errmsg,
+	}
+}
+
+// Succeeded 返回发送成功的号码结果
+func (res SMSMultiResult) Succeeded() []SMSMultiDetail {
+	var ds []SMSMultiDetail
+	for _, d := range res.Detail {
+		if d.Result == SUCCESS {
+			ds = append(ds, d)
+		}
+	}
+
+	return ds
+}
+
+// Failed 返回发送失败的号码结果
+func (res SMSMultiResult) Failed() []SMSMultiDetail {
+	var ds []SMSMultiDetail
+	for _, d := range res.Detail {
+		if d.Result != SUCCESS {
+			ds = append(ds, d)
+		}
+	}
+
+	return ds
 }
 
 // SendSMSMulti 群发短信
-func (c *QcloudSMS) SendSMSMulti(sms SMSMultiReq) (bool, error) {
+//
+// 返回每个号码的发送结果，请求成功时也可能有部分号码发送失败，可通过 Failed 取得
+func (c *QcloudSMS) SendSMSMulti(sms SMSMultiReq) (SMSMultiResult, error) {
+	return c.SendSMSMultiContext(context.Background(), sms)
+}
+
+// SendSMSMultiContext 与 SendSMSMulti 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) SendSMSMultiContext(ctx context.Context, sms SMSMultiReq) (SMSMultiResult, error) {
 	var sigMobile []string
 
 	if len(sms.Tel) > MULTISMSMAX {
-		return false, ErrMultiCount
+		return SMSMultiResult{}, ErrMultiCount
 	}
 
 	for _, m := range sms.Tel {
@@ -115,32 +169,30 @@ func (c *QcloudSMS) SendSMSMulti(sms SMSMultiReq) (bool, error) {
 	}
 
 	mobileStr := strings.Join(sigMobile, ",")
-	c = c.NewSig(mobileStr).NewURL(MULTISMS)
+	r := c.NewRequest().NewSig(mobileStr).NewURL(MULTISMS)
 
-	sms.Time = c.ReqTime
-	sms.Sig = c.Sig
-
-	resp, err := c.NewRequest(sms)
-	if err != nil {
-		return false, err
-	}
+	sms.Time = r.ReqTime
+	sms.Sig = r.Sig
 
 	var res SMSMultiResult
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, sms, &res)
 
-	if res.Result == SUCCESS {
-		return true, nil
-	}
-
-	return false, errors.New(res.Errmsg)
+	return res, err
 }
 
+// 拉取的数据类型，由调用的方法设置
+const (
+	// pullTypeStatus 短信下发状态
+	pullTypeStatus = 0
+	// pullTypeReply 短信回复
+	pullTypeReply = 1
+)
+
 // StatusMobileReq 拉取单个手机短信状态请求结构
+// 拉取的类型由调用的方法决定，GetStatusForMobile 拉取下发状态，GetReplyForMobile 拉取短信回复
 type StatusMobileReq struct {
 	Sig  string `json:"sig"`
 	Time int64  `json:"time"`
-	// 0 1分别代表 短信下发状态，短信回复
-	Type int `json:"type"`
 	// 最大条数 最多100
 	Max        int    `json:"max"`
 	BeginTime  int64  `json:"begin_time"`
@@ -149,6 +201,12 @@ type StatusMobileReq struct {
 	Mobile     string `json:"mobile"`
 }
 
+// statusMobileBody 拉取单个手机短信状态实际发送的请求结构
+type statusMobileBody struct {
+	StatusMobileReq
+	Type int `json:"type"`
+}
+
 // StatusMobileResult 拉取单个手机短信状态的返回结构
 type StatusMobileResult struct {
 	Result int               `json:"result"`
@@ -167,13 +225,15 @@ type StatusReplyResult struct {
 
 // SMSStatusResult 单个手机短信状态结构
 type SMSStatusResult struct {
-	UserReceiveTime string `json:"user_receive_time"`
-	Nationcode      string `json:"nationcode"`
-	Mobile          string `json:"mobile"`
-	ReportStatus    string `json:"report_status"`
-	Errmsg          string `json:"errmsg"`
-	Description     string `json:"description"`
-	Sid             string `json:"sid"`
+	// 用户实际接收到短信的时间
+	UserReceiveTime ReportTime   `json:"user_receive_time"`
+	Nationcode      string       `json:"nationcode"`
+	Mobile          string       `json:"mobile"`
+	ReportStatus    ReportStatus `json:"report_status"`
+	// 运营商返回的错误码，如 DELIVRD，可通过 Category 和 CarrierError 取得分类和说明
+	Errmsg      string `json:"errmsg"`
+	Description string `json:"description"`
+	Sid         string `json:"sid"`
 }
 
 // SMSReplyResult 短信回复列表结构
@@ -190,52 +250,57 @@ type SMSReplyResult struct {
 //
 // https://cloud.tencent.com/document/product/382/5811
 func (c *QcloudSMS) GetStatusForMobile(smr StatusMobileReq) (StatusMobileResult, error) {
-	c = c.NewSig("").NewURL(MOBILESTATUS)
+	return c.GetStatusForMobileContext(context.Background(), smr)
+}
 
-	smr.Time = c.ReqTime
-	smr.Sig = c.Sig
+// GetStatusForMobileContext 与 GetStatusForMobile 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetStatusForMobileContext(ctx context.Context, smr StatusMobileReq) (StatusMobileResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(MOBILESTATUS)
 
-	var res StatusMobileResult
-	resp, err := c.NewRequest(smr)
-	if err != nil {
-		return res, err
-	}
+	smr.Time = r.ReqTime
+	smr.Sig = r.Sig
 
-	json.Unmarshal([]byte(resp), &res)
+	var res StatusMobileResult
+	err := r.call(ctx, statusMobileBody{smr, pullTypeStatus}, &res)
 
-	return res, nil
+	return res, err
 }
 
 // GetReplyForMobile 拉取单个手机短信状态（短信回复）
 //
 // https://cloud.tencent.com/document/product/382/5811
 func (c *QcloudSMS) GetReplyForMobile(smr StatusMobileReq) (StatusReplyResult, error) {
-	c = c.NewSig("").NewURL(MOBILESTATUS)
+	return c.GetReplyForMobileContext(context.Background(), smr)
+}
 
-	smr.Time = c.ReqTime
-	smr.Sig = c.Sig
+// GetReplyForMobileContext 与 GetReplyForMobile 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetReplyForMobileContext(ctx context.Context, smr StatusMobileReq) (StatusReplyResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(MOBILESTATUS)
 
-	var res StatusReplyResult
-	resp, err := c.NewRequest(smr)
-	if err != nil {
-		return res, err
-	}
+	smr.Time = r.ReqTime
+	smr.Sig = r.Sig
 
-	json.Unmarshal([]byte(resp), &res)
+	var res StatusReplyResult
+	err := r.call(ctx, statusMobileBody{smr, pullTypeReply}, &res)
 
-	return res, nil
+	return res, err
 }
 
 // PullStatusReq 拉取短信状态请求结构
+// 拉取的类型由调用的方法决定，PullStatus 拉取下发状态，PullReply 拉取短信回复
 type PullStatusReq struct {
 	Sig  string `json:"sig"`
 	Time int64  `json:"time"`
-	// 0 1分别代表 短信下发状态，短信回复
-	Type int `json:"type"`
 	// 最大条数 最多100
 	Max int `json:"max"`
 }
 
+// pullStatusBody 拉取短信状态实际发送的请求结构
+type pullStatusBody struct {
+	PullStatusReq
+	Type int `json:"type"`
+}
+
 // PullStatusResult 拉取下发状态返回数据结构
 type PullStatusResult struct {
 	Result int    `json:"result"`
@@ -254,23 +319,61 @@ type PullReplyResult struct {
 	Data  []SMSReplyResult `json:"data"`
 }
 
-// GetStatusMQ 拉取短信状态（下发状态,短信回复）
+// PullStatus 拉取短信下发状态
 // 已拉取过的数据将不会再返回
 //
 // https://cloud.tencent.com/document/product/382/5810
-func (c *QcloudSMS) GetStatusMQ(psr PullStatusReq) (StatusMobileResult, error) {
-	c = c.NewSig("").NewURL(PULLSTATUS)
+func (c *QcloudSMS) PullStatus(psr PullStatusReq) (PullStatusResult, error) {
+	return c.PullStatusContext(context.Background(), psr)
+}
 
-	psr.Time = c.ReqTime
-	psr.Sig = c.Sig
+// PullStatusContext 与 PullStatus 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) PullStatusContext(ctx context.Context, psr PullStatusReq) (PullStatusResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(PULLSTATUS)
 
-	resp, err := c.NewRequest(psr)
-	if err != nil {
-		return StatusMobileResult{}, err
-	}
+	psr.Time = r.ReqTime
+	psr.Sig = r.Sig
 
-	var res StatusMobileResult
-	json.Unmarshal([]byte(resp), &res)
+	var res PullStatusResult
+	err := r.call(ctx, pullStatusBody{psr, pullTypeStatus}, &res)
+
+	return res, err
+}
+
+// PullReply 拉取短信回复
+// 已拉取过的数据将不会再返回
+//
+// https://cloud.tencent.com/document/product/382/5810
+func (c *QcloudSMS) PullReply(psr PullStatusReq) (PullReplyResult, error) {
+	return c.PullReplyContext(context.Background(), psr)
+}
+
+// PullReplyContext 与 PullReply 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) PullReplyContext(ctx context.Context, psr PullStatusReq) (PullReplyResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(PULLSTATUS)
+
+	psr.Time = r.ReqTime
+	psr.Sig = r.Sig
+
+	var res PullReplyResult
+	err := r.call(ctx, pullStatusBody{psr, pullTypeReply}, &res)
+
+	return res, err
+}
+
+// GetStatusMQ 拉取短信下发状态
+// 已拉取过的数据将不会再返回
+//
+// Deprecated: 使用 PullStatus 拉取下发状态，PullReply 拉取短信回复
+func (c *QcloudSMS) GetStatusMQ(psr PullStatusReq) (StatusMobileResult, error) {
+	return c.GetStatusMQContext(context.Background(), psr)
+}
+
+// GetStatusMQContext 与 GetStatusMQ 相同，请求的取消、超时等由 ctx 控制
+//
+// Deprecated: 使用 PullStatusContext 拉取下发状态，PullReplyContext 拉取短信回复
+func (c *QcloudSMS) GetStatusMQContext(ctx context.Context, psr PullStatusReq) (StatusMobileResult, error) {
+	res, err := c.PullStatusContext(ctx, psr)
 
-	return res, nil
+	return StatusMobileResult(res), err
 }
@@ -1,7 +1,7 @@
 package qcloudsms
 
 import (
-	"encoding/json"
+	"context"
 )
 
 // StatusReq 查询回执数据请求结构
@@ -61,45 +61,45 @@ type SendStatusResult struct {
 //
 // https://cloud.tencent.com/document/product/382/7756
 func (c *QcloudSMS) GetStatus(begin, end uint32) (StatusResult, error) {
-	c = c.NewSig("").NewURL(PULLCBSTATUS)
+	return c.GetStatusContext(context.Background(), begin, end)
+}
+
+// GetStatusContext 与 GetStatus 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetStatusContext(ctx context.Context, begin, end uint32) (StatusResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(PULLCBSTATUS)
 
 	var cbs = StatusReq{
-		Sig:       c.Sig,
-		Time:      c.ReqTime,
+		Sig:       r.Sig,
+		Time:      r.ReqTime,
 		BeginDate: begin,
 		EndDate:   end,
 	}
 
 	var res StatusResult
-	resp, err := c.NewRequest(cbs)
-	if err != nil {
-		return res, err
-	}
-
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, cbs, &res)
 
-	return res, nil
+	return res, err
 }
 
 // GetSendStatus 发送数据统计
 //
 // https://cloud.tencent.com/document/product/382/7755
 func (c *QcloudSMS) GetSendStatus(begin, end uint32) (SendStatusResult, error) {
-	c = c.NewSig("").NewURL(PULLSENDSTATUS)
+	return c.GetSendStatusContext(context.Background(), begin, end)
+}
+
+// GetSendStatusContext 与 GetSendStatus 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetSendStatusContext(ctx context.Context, begin, end uint32) (SendStatusResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(PULLSENDSTATUS)
 	var cs = SendStatusReq{
-		Sig:       c.Sig,
-		Time:      c.ReqTime,
+		Sig:       r.Sig,
+		Time:      r.ReqTime,
 		BeginDate: begin,
 		EndDate:   end,
 	}
 
 	var res SendStatusResult
-	resp, err := c.NewRequest(cs)
-	if err != nil {
-		return res, err
-	}
-
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, cs, &res)
 
-	return res, nil
+	return res, err
 }
@@ -1,7 +1,7 @@
 package qcloudsms
 
 import (
-	"encoding/json"
+	"context"
 )
 
 // TemplateGetReq 查询模板状态请求结构
@@ -68,47 +68,47 @@ type TemplateResult struct {
 //
 // https://cloud.tencent.com/document/product/382/5819
 func (c *QcloudSMS) GetTemplateByID(id []uint) (TemplateGetResult, error) {
-	c = c.NewSig("").NewURL(GETTEMPLATE)
+	return c.GetTemplateByIDContext(context.Background(), id)
+}
+
+// GetTemplateByIDContext 与 GetTemplateByID 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetTemplateByIDContext(ctx context.Context, id []uint) (TemplateGetResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(GETTEMPLATE)
 
 	var t = TemplateGetReq{
-		Sig:   c.Sig,
-		Time:  c.ReqTime,
+		Sig:   r.Sig,
+		Time:  r.ReqTime,
 		TplID: id,
 	}
 
 	var res TemplateGetResult
-	resp, err := c.NewRequest(t)
-	if err != nil {
-		return res, err
-	}
+	err := r.call(ctx, t, &res)
 
-	json.Unmarshal([]byte(resp), &res)
-
-	return res, nil
+	return res, err
 }
 
 // GetTemplateByPage 用于批量获取模板数据
 // 参数为偏移量，拉取条数
 func (c *QcloudSMS) GetTemplateByPage(offset, max uint) (TemplateGetResult, error) {
-	c = c.NewSig("").NewURL(GETTEMPLATE)
+	return c.GetTemplateByPageContext(context.Background(), offset, max)
+}
+
+// GetTemplateByPageContext 与 GetTemplateByPage 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) GetTemplateByPageContext(ctx context.Context, offset, max uint) (TemplateGetResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(GETTEMPLATE)
 
 	var t = TemplateGetReq{
-		Sig:  c.Sig,
-		Time: c.ReqTime,
+		Sig:  r.Sig,
+		Time: r.ReqTime,
 	}
 
 	t.TplPage.Offset = offset
 	t.TplPage.Max = max
 
 	var res TemplateGetResult
-	resp, err := c.NewRequest(t)
-	if err != nil {
-		return res, err
-	}
-
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, t, &res)
 
-	return res, nil
+	return res, err
 }
 
 // NewTemplate 新建模板
@@ -116,20 +116,20 @@ func (c *QcloudSMS) GetTemplateByPage(offset, max uint) (TemplateGetResult, erro
 //
 // https://cloud.tencent.com/document/product/382/5817
 func (c *QcloudSMS) NewTemplate(t TemplateNew) (TemplateResult, error) {
-	c = c.NewSig("").NewURL(ADDTEMPLATE)
+	return c.NewTemplateContext(context.Background(), t)
+}
 
-	t.Time = c.ReqTime
-	t.Sig = c.Sig
+// NewTemplateContext 与 NewTemplate 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) NewTemplateContext(ctx context.Context, t TemplateNew) (TemplateResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(ADDTEMPLATE)
 
-	var res TemplateResult
-	resp, err := c.NewRequest(t)
-	if err != nil {
-		return res, err
-	}
+	t.Time = r.ReqTime
+	t.Sig = r.Sig
 
-	json.Unmarshal([]byte(resp), &res)
+	var res TemplateResult
+	err := r.call(ctx, t, &res)
 
-	return res, nil
+	return res, err
 }
 
 // ModTemplate 修改模板
@@ -137,20 +137,20 @@ func (c *QcloudSMS) NewTemplate(t TemplateNew) (TemplateResult, error) {
 //
 // https://cloud.tencent.com/document/product/382/8649
 func (c *QcloudSMS) ModTemplate(t TemplateNew) (TemplateResult, error) {
-	c = c.NewSig("").NewURL(MODTEMPLATE)
+	return c.ModTemplateContext(context.Background(), t)
+}
 
-	t.Time = c.ReqTime
-	t.Sig = c.Sig
+// ModTemplateContext 与 ModTemplate 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) ModTemplateContext(ctx context.Context, t TemplateNew) (TemplateResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(MODTEMPLATE)
 
-	var res TemplateResult
-	resp, err := c.NewRequest(t)
-	if err != nil {
-		return res, err
-	}
+	t.Time = r.ReqTime
+	t.Sig = r.Sig
 
-	json.Unmarshal([]byte(resp), &res)
+	var res TemplateResult
+	err := r.call(ctx, t, &res)
 
-	return res, nil
+	return res, err
 }
 
 // DelTemplate 删除模板
@@ -158,21 +158,21 @@ func (c *QcloudSMS) ModTemplate(t TemplateNew) (TemplateResult, error) {
 //
 // https://cloud.tencent.com/document/product/382/5818
 func (c *QcloudSMS) DelTemplate(id []uint) (TemplateResult, error) {
-	c = c.NewSig("").NewURL(DELTEMPLATE)
+	return c.DelTemplateContext(context.Background(), id)
+}
+
+// DelTemplateContext 与 DelTemplate 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) DelTemplateContext(ctx context.Context, id []uint) (TemplateResult, error) {
+	r := c.NewRequest().NewSig("").NewURL(DELTEMPLATE)
 
 	var t = TemplateDelReq{
-		Time:  c.ReqTime,
-		Sig:   c.Sig,
+		Time:  r.ReqTime,
+		Sig:   r.Sig,
 		TplID: id,
 	}
 
 	var res TemplateResult
-	resp, err := c.NewRequest(t)
-	if err != nil {
-		return res, err
-	}
-
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, t, &res)
 
-	return res, nil
+	return res, err
 }
@@ -1,9 +1,7 @@
 package qcloudsms
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
+	"context"
 )
 
 // VoiceReq 语音接口请求结构
@@ -35,13 +33,20 @@ type VoiceResult struct {
 	Result uint   `json:"result"`
 	Errmsg string `json:"errmsg"`
 	Ext    string `json:"ext"`
+	// 本次呼叫标识，用于关联语音的状态通知、按键通知和失败原因推送
 	Callid string `json:"callid"`
 }
 
 // SendVoice 执行发送语音的逻辑
 //
 // 此接口整合了语音验证码和语音通知，使用时根据相应的参数构造请求体即可。
-func (c *QcloudSMS) SendVoice(v VoiceReq) (bool, error) {
+// 返回结果中的 Callid 用于关联之后的语音推送。
+func (c *QcloudSMS) SendVoice(v VoiceReq) (VoiceResult, error) {
+	return c.SendVoiceContext(context.Background(), v)
+}
+
+// SendVoiceContext 与 SendVoice 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) SendVoiceContext(ctx context.Context, v VoiceReq) (VoiceResult, error) {
 	var api string
 	// 根据Prompttype类型验证是验证码还是普通通知，构造不同的请求URL
 	if v.Prompttype == PROMPTVOICETYPE {
@@ -50,24 +55,15 @@ func (c *QcloudSMS) SendVoice(v VoiceReq) (bool, error) {
 		api = SENDVOICE
 	}
 
-	c = c.NewSig(v.Tel.Mobile).NewURL(api)
+	r := c.NewRequest().NewSig(v.Tel.Mobile).NewURL(api)
 
-	v.Sig = c.Sig
-	v.Time = c.ReqTime
-
-	resp, err := c.NewRequest(v)
-	if err != nil {
-		return false, err
-	}
+	v.Sig = r.Sig
+	v.Time = r.ReqTime
 
 	var res VoiceResult
-	json.Unmarshal([]byte(resp), &res)
+	err := r.call(ctx, v, &res)
 
-	if res.Result == SUCCESS {
-		return true, errors.New("发送成功")
-	}
-
-	return false, errors.New(res.Errmsg)
+	return res, err
 }
 
 //选择模板发送语音的参数
@@ -88,25 +84,17 @@ type SMSVoiceTemplate struct {
 }
 
 //根据配置好的模板进行语音发送
-func (c *QcloudSMS) VoiceTemplateSend(s SMSVoiceTemplate) (bool, error) {
-	c.NewSig(s.Tel.Mobile).voiceTemplateNewURL()
-	s.Sig = c.Sig
-	s.Time = c.ReqTime
-	resp, err := c.NewRequest(s)
-	if err != nil {
-		return false, err
-	}
-	var res VoiceResult
-	json.Unmarshal([]byte(resp), &res)
-
-	if res.Result == SUCCESS {
-		return true, errors.New("发送成功")
-	}
-
-	return false, errors.New(res.Errmsg)
+func (c *QcloudSMS) VoiceTemplateSend(s SMSVoiceTemplate) (VoiceResult, error) {
+	return c.VoiceTemplateSendContext(context.Background(), s)
 }
 
-func (c *QcloudSMS) voiceTemplateNewURL() {
-	url := VOICESVR
-	c.URL = VSVR + url + TVOICE + fmt.Sprintf(TLSSMSSVRAfter, c.Options.APPID, c.Random)
+// VoiceTemplateSendContext 与 VoiceTemplateSend 相同，请求的取消、超时等由 ctx 控制
+func (c *QcloudSMS) VoiceTemplateSendContext(ctx context.Context, s SMSVoiceTemplate) (VoiceResult, error) {
+	r := c.NewRequest().NewSig(s.Tel.Mobile).NewURL(TVOICE)
+	s.Sig = r.Sig
+	s.Time = r.ReqTime
+	var res VoiceResult
+	err := r.call(ctx, s, &res)
+
+	return res, err
 }